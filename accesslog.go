@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const accessLogEnv = "ACCESS_LOG"
+
+// accessLogEnabled registra uma linha de log por requisição (método, path, status, tamanho e duração)
+var accessLogEnabled bool
+
+// statusRecorder repassa a resposta ao ResponseWriter original, registrando o status e a
+// quantidade de bytes escritos
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Unwrap expõe o ResponseWriter original para o http.ResponseController
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// statusCode retorna o status enviado; sem escrita explícita o net/http responde 200
+func (s *statusRecorder) statusCode() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+// withAccessLog registra, ao fim de cada requisição, uma linha estruturada no estilo do
+// access log do nginx. O tamanho é o que foi enviado ao cliente (após a compressão gzip).
+func withAccessLog(enabled bool, next http.Handler) http.Handler {
+	if !enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		slog.InfoContext(r.Context(), "Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.statusCode(),
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+		)
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusRecorder_ReportsStatusAndBytes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedCode  int
+		expectedBytes int
+	}{
+		{"explicit status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}, http.StatusNotFound, 9},
+		{"implicit 200", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello "))
+			w.Write([]byte("world"))
+		}, http.StatusOK, 11},
+		{"no body", func(w http.ResponseWriter, r *http.Request) {}, http.StatusOK, 0},
+		{"repeated WriteHeader", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.WriteHeader(http.StatusInternalServerError)
+		}, http.StatusCreated, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			recorder := &statusRecorder{ResponseWriter: rr}
+
+			tc.handler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if status := recorder.statusCode(); status != tc.expectedCode {
+				t.Errorf("got status %d want %d", status, tc.expectedCode)
+			}
+			if recorder.bytes != tc.expectedBytes || rr.Body.Len() != tc.expectedBytes {
+				t.Errorf("got %d bytes recorded (%d written) want %d", recorder.bytes, rr.Body.Len(), tc.expectedBytes)
+			}
+			if rr.Code != tc.expectedCode {
+				t.Errorf("status was not forwarded: got %d want %d", rr.Code, tc.expectedCode)
+			}
+		})
+	}
+}
+
+func TestWithAccessLog(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		logs := captureLogs(t, slog.LevelInfo)
+		rr := httptest.NewRecorder()
+
+		withRequestID(withAccessLog(true, http.HandlerFunc(handler))).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/weather/batch", nil))
+
+		record := findLogRecord(t, logs, "Request completed")
+		if record["method"] != http.MethodPost || record["path"] != "/weather/batch" {
+			t.Errorf("unexpected method/path: %v", record)
+		}
+		if record["status"] != float64(http.StatusTeapot) || record["bytes"] != float64(15) {
+			t.Errorf("unexpected status/bytes: %v", record)
+		}
+		if _, ok := record["duration"].(string); !ok {
+			t.Errorf("expected duration, got %v", record["duration"])
+		}
+		if record["request_id"] != rr.Header().Get(requestIDHeader) {
+			t.Errorf("expected request_id %q, got %v", rr.Header().Get(requestIDHeader), record["request_id"])
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		logs := captureLogs(t, slog.LevelInfo)
+
+		withAccessLog(false, http.HandlerFunc(handler)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if logs.Len() != 0 {
+			t.Errorf("expected no access log, got %s", logs.String())
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+// WeatherAPIAirQuality Struct para a qualidade do ar retornada pela WeatherAPI com aqi=yes
+type WeatherAPIAirQuality struct {
+	CO           *float64 `json:"co"`
+	NO2          *float64 `json:"no2"`
+	O3           *float64 `json:"o3"`
+	SO2          *float64 `json:"so2"`
+	PM25         *float64 `json:"pm2_5"`
+	PM10         *float64 `json:"pm10"`
+	USEPAIndex   *int     `json:"us-epa-index"`
+	GBDefraIndex *int     `json:"gb-defra-index"`
+}
+
+// AirQualityResponse Struct para o objeto air_quality da nossa API (?aqi=true).
+// Concentrações em μg/m³; os índices seguem as escalas da US EPA (1-6) e do DEFRA (1-10).
+type AirQualityResponse struct {
+	PM25         *float64 `json:"pm2_5,omitempty" xml:"pm2_5,omitempty"`
+	PM10         *float64 `json:"pm10,omitempty" xml:"pm10,omitempty"`
+	CO           *float64 `json:"co,omitempty" xml:"co,omitempty"`
+	NO2          *float64 `json:"no2,omitempty" xml:"no2,omitempty"`
+	O3           *float64 `json:"o3,omitempty" xml:"o3,omitempty"`
+	SO2          *float64 `json:"so2,omitempty" xml:"so2,omitempty"`
+	USEPAIndex   *int     `json:"us_epa_index,omitempty" xml:"us_epa_index,omitempty"`
+	GBDefraIndex *int     `json:"gb_defra_index,omitempty" xml:"gb_defra_index,omitempty"`
+}
+
+// newAirQualityResponse converte a qualidade do ar da WeatherAPI para a nossa resposta
+func newAirQualityResponse(aq *WeatherAPIAirQuality) *AirQualityResponse {
+	if aq == nil {
+		return nil
+	}
+	return &AirQualityResponse{
+		PM25:         aq.PM25,
+		PM10:         aq.PM10,
+		CO:           aq.CO,
+		NO2:          aq.NO2,
+		O3:           aq.O3,
+		SO2:          aq.SO2,
+		USEPAIndex:   aq.USEPAIndex,
+		GBDefraIndex: aq.GBDefraIndex,
+	}
+}
+
+// weatherAPIAQIParam converte a opção de qualidade do ar no valor do parâmetro aqi da WeatherAPI.
+// O padrão é "no", já que a qualidade do ar consome mais da cota.
+func weatherAPIAQIParam(includeAirQuality bool) string {
+	if includeAirQuality {
+		return "yes"
+	}
+	return "no"
+}
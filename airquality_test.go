@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const mockAirQualityResponse = `{"current": {"temp_c": 24.0, "air_quality": {"co": 230.3, "no2": 13.5, "o3": 61.2, "so2": 4.1, "pm2_5": 12.4, "pm10": 18.9, "us-epa-index": 1, "gb-defra-index": 2}}}`
+
+func TestWeatherHandler_AirQuality(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = mockAirQualityResponse
+	expectWeatherAPIAQI = "yes"
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?aqi=true", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	aq := response.AirQuality
+	if aq == nil {
+		t.Fatal("expected air_quality in the response")
+	}
+	if aq.PM25 == nil || *aq.PM25 != 12.4 {
+		t.Errorf("unexpected pm2_5: %v", aq.PM25)
+	}
+	if aq.USEPAIndex == nil || *aq.USEPAIndex != 1 {
+		t.Errorf("unexpected us_epa_index: %v", aq.USEPAIndex)
+	}
+	if aq.GBDefraIndex == nil || *aq.GBDefraIndex != 2 {
+		t.Errorf("unexpected gb_defra_index: %v", aq.GBDefraIndex)
+	}
+}
+
+func TestWeatherHandler_AirQualityNotRequestedByDefault(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = mockAirQualityResponse
+	expectWeatherAPIAQI = "no" // O padrão economiza cota
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if _, ok := body["air_quality"]; ok {
+		t.Errorf("expected air_quality to be omitted, got %v", body["air_quality"])
+	}
+}
+
+func TestWeatherHandler_AirQualityNotServedFromPlainCache(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 24.0}}`
+	weatherHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	// A WeatherAPI falha: a leitura sem qualidade do ar não deve ser usada para ?aqi=true
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?aqi=true", nil))
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+	}
+}
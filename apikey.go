@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	validateAPIKeyOnStartEnv = "VALIDATE_API_KEY_ON_START"
+	weatherAPIKeyFileEnv     = "WEATHER_API_KEY_FILE"
+
+	// apiKeyCheckQuery é a cidade usada na chamada de teste da chave
+	apiKeyCheckQuery   = "Brasilia"
+	apiKeyCheckTimeout = 5 * time.Second
+
+	// weatherAPIKeyLength é o tamanho das chaves emitidas pela WeatherAPI
+	weatherAPIKeyLength = 31
+)
+
+// weatherAPIKeyRegex descreve o formato das chaves da WeatherAPI (hexadecimal minúsculo)
+var weatherAPIKeyRegex = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// loadAPIKey lê a chave da WeatherAPI. WEATHER_API_KEY_FILE (ex: um secret do Docker montado
+// em /run/secrets) tem precedência sobre WEATHER_API_KEY; as quebras de linha finais do
+// arquivo são removidas. Um arquivo configurado mas ilegível é um erro, sem recorrer à variável.
+func loadAPIKey() (string, error) {
+	path := os.Getenv(weatherAPIKeyFileEnv)
+	if path == "" {
+		return os.Getenv(weatherAPIEnvVar), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", weatherAPIKeyFileEnv, err)
+	}
+	if os.Getenv(weatherAPIEnvVar) != "" {
+		slog.Warn("Both API key sources set, using the file", "file_env", weatherAPIKeyFileEnv, "env", weatherAPIEnvVar)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// checkAPIKeyFormat aponta problemas comuns na chave configurada, como espaços ou aspas
+// copiados junto com o valor, ou uma chave truncada. Não garante que a chave é válida.
+func checkAPIKeyFormat(key string) error {
+	switch {
+	case key == "":
+		return errors.New("key is empty")
+	case strings.TrimSpace(key) != key:
+		return errors.New("key has leading or trailing whitespace")
+	case strings.ContainsAny(key, `"'`):
+		return errors.New("key contains quotes")
+	case len(key) != weatherAPIKeyLength:
+		return fmt.Errorf("key has %d characters, expected %d", len(key), weatherAPIKeyLength)
+	case !weatherAPIKeyRegex.MatchString(key):
+		return errors.New("key is not lowercase hexadecimal")
+	}
+	return nil
+}
+
+// checkAPIKeyLive faz uma única consulta de teste à WeatherAPI com a chave configurada.
+// Consome uma chamada da cota, por isso só é feita quando VALIDATE_API_KEY_ON_START está ativo.
+func checkAPIKeyLive(ctx context.Context, client *http.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, apiKeyCheckTimeout)
+	defer cancel()
+
+	requestURL := fmt.Sprintf(weatherAPIURLFormat, weatherAPIURL, weatherAPIKey, url.QueryEscape(apiKeyCheckQuery), weatherAPIAQIParam(false))
+	var response WeatherAPIResponse
+	return callWeatherAPIOnce(ctx, client, requestURL, apiKeyCheckQuery, &response)
+}
+
+// validateAPIKey verifica a chave na inicialização, apenas registrando avisos: uma chave
+// suspeita não impede o servidor de subir, mas evita descobrir o problema pelos erros 500
+func validateAPIKey(ctx context.Context, live bool) {
+	if err := checkAPIKeyFormat(weatherAPIKey); err != nil {
+		slog.WarnContext(ctx, "WeatherAPI key looks invalid", "env", weatherAPIEnvVar, "error", err)
+	}
+	if !live {
+		return
+	}
+	if err := checkAPIKeyLive(ctx, defaultClients.WeatherAPI); err != nil {
+		slog.WarnContext(ctx, "WeatherAPI key check failed", "env", weatherAPIEnvVar, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "WeatherAPI key check succeeded")
+}
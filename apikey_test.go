@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckAPIKeyFormat(t *testing.T) {
+	testCases := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"valid", "be4bd84912cb4b25803234739252104", true},
+		{"empty", "", false},
+		{"truncated", "be4bd84912cb4b2580323473925", false},
+		{"trailing newline", "be4bd84912cb4b25803234739252104\n", false},
+		{"quoted", `"be4bd84912cb4b25803234739252104"`, false},
+		{"uppercase", "BE4BD84912CB4B25803234739252104", false},
+		{"not hexadecimal", "zz4bd84912cb4b25803234739252104", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkAPIKeyFormat(tc.key)
+			if (err == nil) != tc.valid {
+				t.Errorf("checkAPIKeyFormat(%q): got error %v, want valid=%t", tc.key, err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestCheckAPIKeyLive(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+	if err := checkAPIKeyLive(context.Background(), defaultClients.WeatherAPI); err != nil {
+		t.Errorf("expected the key check to succeed, got %v", err)
+	}
+
+	mockWeatherAPIStatusCode = http.StatusUnauthorized
+	mockWeatherAPIResponse = `{"error": {"code": 2006, "message": "API key is invalid."}}`
+	if err := checkAPIKeyLive(context.Background(), defaultClients.WeatherAPI); err == nil {
+		t.Error("expected the key check to fail for an invalid key")
+	}
+}
+
+func TestLoadAPIKey(t *testing.T) {
+	const key = "be4bd84912cb4b25803234739252104"
+
+	t.Run("env var", func(t *testing.T) {
+		t.Setenv(weatherAPIKeyFileEnv, "")
+		t.Setenv(weatherAPIEnvVar, key)
+		if got, err := loadAPIKey(); err != nil || got != key {
+			t.Errorf("loadAPIKey() = %q, %v; want %q", got, err, key)
+		}
+	})
+
+	t.Run("file trims trailing newlines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "weather_api_key")
+		if err := os.WriteFile(path, []byte(key+"\r\n\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(weatherAPIKeyFileEnv, path)
+		t.Setenv(weatherAPIEnvVar, "")
+		if got, err := loadAPIKey(); err != nil || got != key {
+			t.Errorf("loadAPIKey() = %q, %v; want %q", got, err, key)
+		}
+	})
+
+	t.Run("file takes precedence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "weather_api_key")
+		if err := os.WriteFile(path, []byte(key), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(weatherAPIKeyFileEnv, path)
+		t.Setenv(weatherAPIEnvVar, "inline-key")
+		if got, err := loadAPIKey(); err != nil || got != key {
+			t.Errorf("loadAPIKey() = %q, %v; want %q", got, err, key)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv(weatherAPIKeyFileEnv, filepath.Join(t.TempDir(), "missing"))
+		t.Setenv(weatherAPIEnvVar, key)
+		if _, err := loadAPIKey(); err == nil {
+			t.Error("expected an error for an unreadable key file, not a fallback to the env var")
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errTooManyAttempts indica que a requisição esgotou o limite de chamadas às APIs externas
+var errTooManyAttempts = errors.New(errorTooManyAttempts)
+
+// attemptBudget conta as chamadas às APIs externas feitas durante uma única requisição
+type attemptBudget struct {
+	max  int32
+	used atomic.Int32
+}
+
+type attemptBudgetKey struct{}
+
+// withAttemptBudget anexa ao contexto um limite de tentativas compartilhado por toda a requisição.
+// Um limite menor ou igual a zero desativa o controle.
+func withAttemptBudget(ctx context.Context, max int) context.Context {
+	if max <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptBudgetKey{}, &attemptBudget{max: int32(max)})
+}
+
+// consumeAttempt registra uma nova chamada externa, falhando rápido quando o limite já foi atingido
+func consumeAttempt(ctx context.Context) error {
+	budget, ok := ctx.Value(attemptBudgetKey{}).(*attemptBudget)
+	if !ok {
+		return nil
+	}
+	if budget.used.Add(1) > budget.max {
+		return errTooManyAttempts
+	}
+	return nil
+}
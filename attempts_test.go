@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherHandler_MaxFallbackAttempts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	// Permite apenas a chamada ao ViaCEP; a chamada à WeatherAPI deve ser barrada
+	maxFallbackAttempts = 1
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusBadGateway {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadGateway)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorTooManyAttempts {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorTooManyAttempts)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected 1 ViaCEP call, got %d", calls)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 0 {
+		t.Errorf("expected the cap to stop the WeatherAPI call, got %d calls", calls)
+	}
+}
+
+func TestConsumeAttempt(t *testing.T) {
+	testCases := []struct {
+		max         int
+		calls       int
+		expectError bool
+	}{
+		{max: 2, calls: 2, expectError: false},
+		{max: 2, calls: 3, expectError: true},
+		{max: 0, calls: 50, expectError: false}, // 0 desativa o limite
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("max=%d,calls=%d", tc.max, tc.calls), func(t *testing.T) {
+			ctx := withAttemptBudget(t.Context(), tc.max)
+
+			var err error
+			for i := 0; i < tc.calls; i++ {
+				err = consumeAttempt(ctx)
+			}
+
+			if (err != nil) != tc.expectError {
+				t.Errorf("unexpected result on the last attempt: got %v, expect error %v", err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_TotalRequestBudget(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	// Cada chamada isolada cabe no prazo, mas juntas o excedem
+	totalRequestBudget = 150 * time.Millisecond
+	mockViaCEPDelay = 100 * time.Millisecond
+	mockWeatherAPIDelay = 100 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	start := time.Now()
+	weatherHandler(rr, req)
+	elapsed := time.Since(start)
+
+	if status := rr.Code; status != http.StatusGatewayTimeout {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusGatewayTimeout)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorRequestTimeout {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorRequestTimeout)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected the handler to give up after the budget, took %s", elapsed)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 1 {
+		t.Errorf("expected the WeatherAPI call to start with the remaining budget, got %d calls", calls)
+	}
+}
+
+func TestWeatherHandler_TotalRequestBudgetNotExceeded(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	totalRequestBudget = time.Second
+	mockViaCEPDelay = 20 * time.Millisecond
+	mockWeatherAPIDelay = 20 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+const basePathEnv = "BASE_PATH"
+
+// basePath é o prefixo sob o qual as rotas são servidas atrás de um proxy reverso (ex: "/api"
+// atende /api/weather/{cep}); vazio serve as rotas na raiz
+var basePath string
+
+// normalizeBasePath padroniza o prefixo configurado: sempre com a barra inicial e sem a
+// final ("api/" -> "/api"). Vazio ou apenas "/" desativa o prefixo.
+func normalizeBasePath(raw string) string {
+	trimmed := strings.Trim(strings.TrimSpace(raw), "/")
+	if trimmed == "" {
+		return ""
+	}
+	return "/" + trimmed
+}
+
+// withBasePath remove o prefixo do path antes do roteamento, de modo que as rotas (e as
+// chaves de RATE_LIMIT_ROUTES) continuem sem ele. Requisições fora do prefixo recebem 404,
+// como qualquer rota desconhecida.
+func withBasePath(prefix string, next http.Handler) http.Handler {
+	if prefix == "" {
+		return next
+	}
+	stripped := http.StripPrefix(prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// "/apiweather" não está sob "/api"
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		stripped.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeBasePath(t *testing.T) {
+	testCases := map[string]string{
+		"":           "",
+		"/":          "",
+		"  ":         "",
+		"/api":       "/api",
+		"api":        "/api",
+		"/api/":      "/api",
+		" /api/v1/ ": "/api/v1",
+	}
+
+	for raw, expected := range testCases {
+		if got := normalizeBasePath(raw); got != expected {
+			t.Errorf("normalizeBasePath(%q) = %q want %q", raw, got, expected)
+		}
+	}
+}
+
+func TestRouter_BasePath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		basePath string
+		path     string
+		expected int
+	}{
+		{"root without base path", "", "/weather/01001000", http.StatusOK},
+		{"prefixed path without base path", "", "/api/weather/01001000", http.StatusNotFound},
+		{"prefixed weather route", "/api", "/api/weather/01001000", http.StatusOK},
+		{"prefixed sub-route", "/api", "/api/weather/01001000/full", http.StatusOK},
+		{"prefixed city route", "/api", "/api/weather/city/S%C3%A3o%20Paulo", http.StatusOK},
+		{"prefixed version route", "/api", "/api/version", http.StatusOK},
+		{"nested base path", "/api/v1", "/api/v1/weather/01001000", http.StatusOK},
+		{"root path with base path", "/api", "/weather/01001000", http.StatusNotFound},
+		{"prefix without separator", "/api", "/apiweather/01001000", http.StatusNotFound},
+		{"malformed path under base path", "/api", "/api/weather/01001000/extra", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			basePath = tc.basePath
+			mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rr.Code != tc.expected {
+				t.Errorf("%s: got status %d want %d (body %q)", tc.path, rr.Code, tc.expected, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_BasePathKeepsRouteRateLimits(t *testing.T) {
+	setup()
+	defer teardown()
+
+	basePath = "/api"
+	routeRateLimiters = map[string]*rateLimiter{"/version": newRateLimiter(0.001, 1)}
+	router := newRouter()
+
+	codes := make([]int, 2)
+	for i := range codes {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+		codes[i] = rr.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("expected the /version limit to apply under the base path, got %v", codes)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Status individuais de cada CEP no lote
+const (
+	batchStatusOK       = "ok"
+	batchStatusInvalid  = "invalid"
+	batchStatusNotFound = "not_found"
+	batchStatusError    = "error"
+)
+
+const maxBatchBodyBytes = 1 << 20 // 1 MiB
+
+const (
+	maxCEPsPerRequestEnv     = "MAX_CEPS_PER_REQUEST"
+	defaultMaxCEPsPerRequest = 10
+)
+
+// maxCEPsPerRequest limita os CEPs separados por vírgula em /weather/{cep1},{cep2}
+var maxCEPsPerRequest = defaultMaxCEPsPerRequest
+
+// BatchResult Struct para o resultado de um CEP dentro da resposta do lote
+type BatchResult struct {
+	CEP     string           `json:"cep" xml:"cep"`
+	Status  string           `json:"status" xml:"status"`
+	Error   string           `json:"error,omitempty" xml:"error,omitempty"`
+	Weather *WeatherResponse `json:"weather,omitempty" xml:"weather,omitempty"`
+}
+
+// BatchResults lista de resultados do lote. Em JSON é um array simples; em XML é
+// envolvida por <results>, já que um documento XML precisa de um único elemento raiz.
+type BatchResults []BatchResult
+
+// MarshalXML serializa o lote como <results><result>...</result></results>
+func (b BatchResults) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "results"
+	return e.EncodeElement(struct {
+		Results []BatchResult `xml:"result"`
+	}{b}, start)
+}
+
+// batchHandler atende POST /weather/batch, recebendo um array JSON de CEPs e retornando
+// um resultado por CEP, na mesma ordem. Falhas individuais não derrubam o lote inteiro.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)) // 405
+		return
+	}
+
+	var ceps []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&ceps); err != nil {
+		writeError(w, r, http.StatusBadRequest, errorInvalidBatchBody) // 400
+		return
+	}
+	if len(ceps) == 0 || len(ceps) > batchMaxSize {
+		writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("batch must contain between 1 and %d CEPs", batchMaxSize)) // 422
+		return
+	}
+
+	opts, err := parseResponseOptions(r)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+		return
+	}
+
+	// O lote inteiro respeita o TOTAL_REQUEST_BUDGET, como uma consulta individual
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	results := resolveBatch(ctx, ceps, opts, batchConcurrency)
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+// multiCEPHandler atende GET /weather/{cep1},{cep2},..., retornando um resultado por CEP na
+// ordem do path, no mesmo formato do lote. Cada CEP segue o fluxo de /weather/{cep}.
+func multiCEPHandler(w http.ResponseWriter, r *http.Request, rawCEPs string) {
+	ceps := strings.Split(rawCEPs, ",")
+	for i := range ceps {
+		ceps[i] = strings.TrimSpace(ceps[i])
+	}
+	if len(ceps) > maxCEPsPerRequest {
+		writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("request must contain at most %d CEPs", maxCEPsPerRequest)) // 422
+		return
+	}
+
+	opts, err := parseResponseOptions(r)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+		return
+	}
+
+	// O lote inteiro respeita o TOTAL_REQUEST_BUDGET, como uma consulta individual
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	results := resolveBatch(ctx, ceps, opts, batchConcurrency)
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+// resolveBatch resolve os CEPs concorrentemente usando um pool limitado de workers,
+// preservando a ordem de entrada nos resultados. CEPs repetidos são resolvidos uma única
+// vez, e o resultado se repete em cada posição em que o CEP aparece.
+func resolveBatch(ctx context.Context, ceps []string, opts responseOptions, concurrency int) BatchResults {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// Posições de cada CEP na entrada, na ordem da primeira ocorrência
+	var unique []string
+	positions := make(map[string][]int, len(ceps))
+	for i, cep := range ceps {
+		if _, seen := positions[cep]; !seen {
+			unique = append(unique, cep)
+		}
+		positions[cep] = append(positions[cep], i)
+	}
+
+	results := make(BatchResults, len(ceps))
+	jobs := make(chan string)
+
+	var wg sync.WaitGroup
+	for range min(concurrency, len(unique)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cep := range jobs {
+				result := resolveBatchItem(ctx, cep, opts)
+				// Cada CEP tem posições exclusivas, então os workers não escrevem no mesmo índice
+				for _, i := range positions[cep] {
+					results[i] = result
+				}
+			}
+		}()
+	}
+
+	for _, cep := range unique {
+		jobs <- cep
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// resolveBatchItem executa o mesmo fluxo de /weather/{cep} para um único CEP do lote
+func resolveBatchItem(ctx context.Context, cep string, opts responseOptions) BatchResult {
+	result := BatchResult{CEP: cep}
+
+	if !isValidCEP(cep) {
+		result.Status = batchStatusInvalid
+		result.Error = errorInvalidZipcode
+		return result
+	}
+
+	// Cada CEP tem seu próprio limite de tentativas; o prazo é o do lote
+	ctx = withAttemptBudget(ctx, maxFallbackAttempts)
+
+	location, _, err := lookupCEP(ctx, defaultClients, cep, true)
+	if err != nil {
+		return batchErrorResult(ctx, result, err)
+	}
+
+	weather, err := getWeatherForCity(ctx, defaultClients, location, opts.AirQuality)
+	if err != nil {
+		return batchErrorResult(ctx, result, err)
+	}
+
+	response := newWeatherResponse(weather, opts)
+	response.Approximate = location.Approximate
+	result.Status = batchStatusOK
+	result.Weather = &response
+	return result
+}
+
+// batchErrorResult preenche o status e a mensagem de um CEP que falhou
+func batchErrorResult(ctx context.Context, result BatchResult, err error) BatchResult {
+	status, message := upstreamErrorStatus(err)
+	if status == http.StatusNotFound {
+		result.Status = batchStatusNotFound
+	} else {
+		result.Status = batchStatusError
+	}
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "Error resolving CEP in batch", "cep", result.CEP, "status", status, "error", err)
+	}
+	result.Error = message
+	return result
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchHandler_MixedInput(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPByCEP = map[string]string{
+		"01001000": `{"localidade": "São Paulo"}`,
+		"20040002": `{"localidade": "Rio de Janeiro"}`,
+		"99999999": `{"erro": true}`,
+	}
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	body := `["01001000", "123", "99999999", "20040002", "abcdefgh"]`
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	var results []BatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+
+	expected := []struct {
+		cep    string
+		status string
+	}{
+		{"01001000", batchStatusOK},
+		{"123", batchStatusInvalid},
+		{"99999999", batchStatusNotFound},
+		{"20040002", batchStatusOK},
+		{"abcdefgh", batchStatusInvalid},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+
+	for i, exp := range expected {
+		result := results[i]
+		if result.CEP != exp.cep || result.Status != exp.status {
+			t.Errorf("result %d: got cep=%s status=%s want cep=%s status=%s", i, result.CEP, result.Status, exp.cep, exp.status)
+		}
+		if exp.status == batchStatusOK {
+			if result.Weather == nil || result.Weather.TempC != 25.0 {
+				t.Errorf("result %d: expected temperatures, got %+v", i, result.Weather)
+			}
+		} else if result.Weather != nil || result.Error == "" {
+			t.Errorf("result %d: expected error without temperatures, got %+v", i, result)
+		}
+	}
+
+	// CEPs inválidos não devem chegar ao ViaCEP
+	if calls := mockViaCEPCalls.Load(); calls != 3 {
+		t.Errorf("expected 3 ViaCEP calls, got %d", calls)
+	}
+}
+
+func TestBatchHandler_UpstreamFailureIsPerItem(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(`["01001000", "1"]`))
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var results []BatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if results[0].Status != batchStatusError || results[0].Error != errorInternalServer {
+		t.Errorf("unexpected result for failing upstream: %+v", results[0])
+	}
+	if results[1].Status != batchStatusInvalid {
+		t.Errorf("unexpected result for invalid CEP: %+v", results[1])
+	}
+}
+
+func TestBatchHandler_TotalRequestBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"batch", http.MethodPost, "/weather/batch", `["01001000", "20040002"]`},
+		{"multiple CEPs", http.MethodGet, "/weather/01001000,20040002", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+			totalRequestBudget = 100 * time.Millisecond
+			mockWeatherAPIDelay = 500 * time.Millisecond
+
+			start := time.Now()
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			elapsed := time.Since(start)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+			}
+			if elapsed >= 500*time.Millisecond {
+				t.Errorf("expected the batch to stop at the request budget, took %s", elapsed)
+			}
+
+			var results []BatchResult
+			if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			for i, result := range results {
+				if result.Status != batchStatusError || result.Error != errorRequestTimeout {
+					t.Errorf("result %d: expected the budget error, got %+v", i, result)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchHandler_InvalidRequests(t *testing.T) {
+	setup()
+	defer teardown()
+
+	batchMaxSize = 2
+
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"not a JSON array", http.MethodPost, `{"cep": "01001000"}`, http.StatusBadRequest},
+		{"empty batch", http.MethodPost, `[]`, http.StatusUnprocessableEntity},
+		{"batch too large", http.MethodPost, `["01001000", "01001001", "01001002"]`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/weather/batch", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			newRouter().ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+		})
+	}
+
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls for rejected batches, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_MultipleCEPs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPByCEP = map[string]string{
+		"01001000": `{"localidade": "São Paulo"}`,
+		"20040002": `{"localidade": "Rio de Janeiro"}`,
+	}
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/20040002,01001000", nil))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	var results []BatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	// A ordem do path é preservada
+	for i, cep := range []string{"20040002", "01001000"} {
+		result := results[i]
+		if result.CEP != cep || result.Status != batchStatusOK || result.Weather == nil || result.Weather.TempC != 25.0 {
+			t.Errorf("result %d: got %+v want cep=%s with temperatures", i, result, cep)
+		}
+	}
+}
+
+func TestWeatherHandler_MultipleCEPsOverCap(t *testing.T) {
+	setup()
+	defer teardown()
+
+	maxCEPsPerRequest = 2
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000,20040002,30140071", nil))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != "request must contain at most 2 CEPs" {
+		t.Errorf("handler returned unexpected body: %q", body)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no ViaCEP calls for an over-cap request, got %d", calls)
+	}
+}
+
+func TestBatchHandler_DuplicateCEPsResolvedOnce(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPByCEP = map[string]string{
+		"01001000": `{"localidade": "São Paulo"}`,
+		"20040002": `{"localidade": "Rio de Janeiro"}`,
+		"99999999": `{"erro": true}`,
+	}
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	body := `["01001000", "20040002", "01001000", "123", "99999999", "20040002", "123", "99999999", "01001000"]`
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(body)))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	var results []BatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+
+	expected := []struct {
+		cep    string
+		status string
+	}{
+		{"01001000", batchStatusOK},
+		{"20040002", batchStatusOK},
+		{"01001000", batchStatusOK},
+		{"123", batchStatusInvalid},
+		{"99999999", batchStatusNotFound},
+		{"20040002", batchStatusOK},
+		{"123", batchStatusInvalid},
+		{"99999999", batchStatusNotFound},
+		{"01001000", batchStatusOK},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for i, exp := range expected {
+		result := results[i]
+		if result.CEP != exp.cep || result.Status != exp.status {
+			t.Errorf("result %d: got cep=%s status=%s want cep=%s status=%s", i, result.CEP, result.Status, exp.cep, exp.status)
+		}
+		if exp.status == batchStatusOK && (result.Weather == nil || result.Weather.TempC != 25.0) {
+			t.Errorf("result %d: expected temperatures, got %+v", i, result.Weather)
+		}
+	}
+
+	// Uma chamada por CEP único e válido: 01001000, 20040002 e 99999999 no ViaCEP, e os dois
+	// encontrados na WeatherAPI
+	if calls := mockViaCEPCalls.Load(); calls != 3 {
+		t.Errorf("expected 3 ViaCEP calls, got %d", calls)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 2 {
+		t.Errorf("expected 2 WeatherAPI calls, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_MultipleCEPsWithDuplicates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPByCEP = map[string]string{
+		"01001000": `{"localidade": "São Paulo"}`,
+		"20040002": `{"localidade": "Rio de Janeiro"}`,
+	}
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/20040002,01001000,20040002", nil))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	var results []BatchResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	ceps := []string{"20040002", "01001000", "20040002"}
+	if len(results) != len(ceps) {
+		t.Fatalf("expected %d results, got %d", len(ceps), len(results))
+	}
+	for i, cep := range ceps {
+		if results[i].CEP != cep || results[i].Status != batchStatusOK {
+			t.Errorf("result %d: got %+v want cep=%s", i, results[i], cep)
+		}
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 2 {
+		t.Errorf("expected one ViaCEP call per unique CEP, got %d", calls)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	circuitBreakerThresholdEnv = "CIRCUIT_BREAKER_THRESHOLD"
+	circuitBreakerCooldownEnv  = "CIRCUIT_BREAKER_COOLDOWN"
+
+	defaultCircuitBreakerThreshold = 5
+	defaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
+// errCircuitOpen indica que o circuito da WeatherAPI está aberto e a chamada nem foi feita
+var errCircuitOpen = errors.New(errorCircuitOpen)
+
+// weatherBreaker protege as chamadas à WeatherAPI; nil desativa o controle
+var weatherBreaker *circuitBreaker
+
+// circuitState é o estado de um circuit breaker
+type circuitState int
+
+const (
+	circuitClosed   circuitState = iota // Chamadas liberadas
+	circuitOpen                         // Chamadas bloqueadas até o fim do cooldown
+	circuitHalfOpen                     // Uma chamada de teste liberada para verificar a recuperação
+)
+
+// String retorna o nome do estado, usado nos logs
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker abre o circuito após threshold falhas consecutivas, rejeitando as chamadas
+// durante o cooldown. Depois disso, libera uma única chamada de teste (half-open): sucesso
+// fecha o circuito e falha o abre novamente.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+	state     circuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool             // Há uma chamada de teste em andamento no estado half-open
+	now       func() time.Time // Injetável para testes
+}
+
+// newCircuitBreaker cria um circuit breaker fechado
+func newCircuitBreaker(name string, threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow informa se uma chamada pode ser feita agora
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(circuitHalfOpen)
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record registra o resultado de uma chamada liberada por allow
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.state == circuitHalfOpen
+	b.probing = false
+
+	switch {
+	case err == nil:
+		b.failures = 0
+		if b.state != circuitClosed {
+			b.transition(circuitClosed)
+		}
+	case !isBreakerFailure(err):
+		// Resultado que não diz nada sobre a saúde do provedor: apenas libera a chamada de teste
+	case wasProbe:
+		b.open()
+	default:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+// open abre o circuito; deve ser chamado com o lock adquirido
+func (b *circuitBreaker) open() {
+	b.openedAt = b.now()
+	b.failures = 0
+	b.transition(circuitOpen)
+}
+
+// transition muda o estado registrando a mudança no log; deve ser chamado com o lock adquirido
+func (b *circuitBreaker) transition(state circuitState) {
+	slog.Warn("Circuit breaker state changed", "breaker", b.name, "from", b.state.String(), "to", state.String())
+	b.state = state
+}
+
+// isBreakerFailure indica se o erro conta como falha do provedor. "Não encontrado" é uma
+// resposta válida, e o limite de tentativas, a falta de vagas no semáforo local, o
+// cancelamento pelo cliente ou o prazo do ?timeout= não refletem a saúde da WeatherAPI.
+func isBreakerFailure(err error) bool {
+	return !errors.Is(err, errCannotFindZip) &&
+		!errors.Is(err, errTooManyAttempts) &&
+		!errors.Is(err, errUpstreamBusy) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, errClientTimeout)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := newCircuitBreaker("test", 3, time.Minute)
+	breaker.now = func() time.Time { return now }
+	failure := errors.New("upstream failure")
+
+	// Falhas abaixo do limite mantêm o circuito fechado; um sucesso zera a contagem
+	breaker.record(failure)
+	breaker.record(failure)
+	breaker.record(nil)
+	breaker.record(failure)
+	breaker.record(failure)
+	if !breaker.allow() || breaker.state != circuitClosed {
+		t.Fatalf("expected closed circuit, got %s", breaker.state)
+	}
+
+	breaker.record(failure)
+	if breaker.allow() || breaker.state != circuitOpen {
+		t.Fatalf("expected open circuit after 3 consecutive failures, got %s", breaker.state)
+	}
+
+	// Após o cooldown, apenas uma chamada de teste é liberada
+	now = now.Add(time.Minute)
+	if !breaker.allow() || breaker.state != circuitHalfOpen {
+		t.Fatalf("expected half-open circuit after cooldown, got %s", breaker.state)
+	}
+	if breaker.allow() {
+		t.Error("expected only one probe while half-open")
+	}
+
+	// A chamada de teste falhou: o circuito volta a abrir
+	breaker.record(failure)
+	if breaker.allow() || breaker.state != circuitOpen {
+		t.Fatalf("expected open circuit after failed probe, got %s", breaker.state)
+	}
+
+	now = now.Add(time.Minute)
+	breaker.allow()
+	breaker.record(nil)
+	if !breaker.allow() || breaker.state != circuitClosed {
+		t.Errorf("expected closed circuit after successful probe, got %s", breaker.state)
+	}
+}
+
+func TestCircuitBreaker_IgnoresNotFound(t *testing.T) {
+	breaker := newCircuitBreaker("test", 1, time.Minute)
+
+	breaker.record(errCannotFindZip)
+	breaker.record(errTooManyAttempts)
+
+	if !breaker.allow() {
+		t.Error("expected definitive answers not to trip the breaker")
+	}
+}
+
+func TestWeatherHandler_CircuitBreaker(t *testing.T) {
+	setup()
+	defer teardown()
+
+	now := time.Now()
+	weatherBreaker = newCircuitBreaker("weatherapi", 2, 30*time.Second)
+	weatherBreaker.now = func() time.Time { return now }
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := doRequest(); rr.Code != http.StatusInternalServerError {
+			t.Fatalf("failing request %d: got status %v want %v", i+1, rr.Code, http.StatusInternalServerError)
+		}
+	}
+
+	// Circuito aberto: responde 503 sem consultar a WeatherAPI
+	rr := doRequest()
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorCircuitOpen {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorCircuitOpen)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 2 {
+		t.Errorf("expected the open circuit to skip WeatherAPI, got %d calls", calls)
+	}
+
+	// Depois do cooldown a WeatherAPI se recuperou e a chamada de teste fecha o circuito
+	now = now.Add(30 * time.Second)
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+	mockWeatherAPIStatusCode = http.StatusOK
+
+	for i := 0; i < 2; i++ {
+		if rr := doRequest(); rr.Code != http.StatusOK {
+			t.Errorf("recovered request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+	if weatherBreaker.state != circuitClosed {
+		t.Errorf("expected closed circuit after recovery, got %s", weatherBreaker.state)
+	}
+}
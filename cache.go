@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries limita o número de entradas de cada cache, e com ele o uso de memória
+const maxCacheEntries = 10000
+
+// cacheEntry guarda um valor e o momento em que foi armazenado
+type cacheEntry[V any] struct {
+	value    V
+	storedAt time.Time
+}
+
+// ttlCache é um cache em memória seguro para uso concorrente. Entradas mais antigas que
+// maxAge deixam de ser retornadas e são descartadas.
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry[V]
+	maxAge  func() time.Duration // Função para refletir alterações de configuração
+	now     func() time.Time     // Injetável para testes
+}
+
+// newTTLCache cria um cache cujas entradas expiram após o tempo retornado por maxAge
+func newTTLCache[V any](maxAge func() time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		entries: make(map[string]cacheEntry[V]),
+		maxAge:  maxAge,
+		now:     time.Now,
+	}
+}
+
+// get retorna o valor armazenado e há quanto tempo ele foi guardado
+func (c *ttlCache[V]) get(key string) (value V, age time.Duration, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return value, 0, false
+	}
+
+	age = c.now().Sub(entry.storedAt)
+	if age > c.maxAge() {
+		delete(c.entries, key)
+		return value, 0, false
+	}
+	return entry.value, age, true
+}
+
+// set armazena um valor, substituindo o anterior. Com o cache cheio, as entradas expiradas
+// são descartadas e, se ainda não houver espaço, a mais antiga dá lugar à nova.
+func (c *ttlCache[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxCacheEntries {
+		c.evictExpired(now)
+		if len(c.entries) >= maxCacheEntries {
+			c.evictOldest()
+		}
+	}
+	c.entries[key] = cacheEntry[V]{value: value, storedAt: now}
+}
+
+// clear remove todas as entradas
+func (c *ttlCache[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// evictExpired remove as entradas expiradas; deve ser chamado com o lock adquirido
+func (c *ttlCache[V]) evictExpired(now time.Time) {
+	maxAge := c.maxAge()
+	for key, entry := range c.entries {
+		if now.Sub(entry.storedAt) > maxAge {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// evictOldest remove a entrada guardada há mais tempo; deve ser chamado com o lock adquirido
+func (c *ttlCache[V]) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+const (
+	staleGracePeriodEnv     = "STALE_GRACE_PERIOD"
+	weatherCacheTTLEnv      = "WEATHER_CACHE_TTL"
+	disableCacheEnv         = "DISABLE_CACHE"
+	cepCacheTTLEnv          = "CEP_CACHE_TTL"
+	defaultStaleGracePeriod = 30 * time.Minute
+	defaultWeatherCacheTTL  = 10 * time.Minute // O clima não muda de segundo a segundo
+	defaultCEPCacheTTL      = 24 * time.Hour   // Um CEP raramente muda de cidade
+
+	// cacheHeader informa se o CEP foi resolvido pelo cache (HIT) ou consultado (MISS)
+	cacheHeader = "X-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+)
+
+var (
+	// cacheDisabled desliga todos os caches em memória, para implantações que precisam
+	// sempre de dados frescos ou que ficam atrás de um cache externo
+	cacheDisabled bool
+
+	// weatherCacheTTL é o período em que uma leitura é considerada fresca e servida sem
+	// consultar a WeatherAPI. Com 0, toda requisição consulta a WeatherAPI e o cache serve
+	// apenas como reserva.
+	weatherCacheTTL = defaultWeatherCacheTTL
+	// staleGracePeriod é quanto tempo após o TTL uma leitura ainda pode ser servida
+	// quando a WeatherAPI falha (stale-while-error); 0 desativa esse comportamento
+	staleGracePeriod = defaultStaleGracePeriod
+
+	// weatherCache guarda as últimas leituras da WeatherAPI por consulta (cidade ou coordenadas)
+	weatherCache = newTTLCache[*WeatherAPIResponse](func() time.Duration {
+		return weatherCacheTTL + staleGracePeriod
+	})
+
+	// cepCacheTTL é por quanto tempo uma resolução de CEP é reaproveitada; 0 desativa
+	cepCacheTTL = defaultCEPCacheTTL
+	// cepCache guarda as resoluções bem-sucedidas de CEP (cidade, UF e coordenadas)
+	cepCache = newTTLCache[cepLocation](func() time.Duration { return cepCacheTTL })
+)
+
+// lookupCEP resolve o CEP consultando primeiro o cache; hit indica se a resposta veio dele.
+// Apenas resoluções bem-sucedidas são guardadas, para que falhas transitórias não persistam.
+// CEPs fora das faixas atendidas (ALLOWED_CEP_PREFIXES) são recusados antes de qualquer consulta.
+// Rotas que precisam apenas da cidade passam withCoordinates false e não consultam a BrasilAPI;
+// uma entrada guardada assim é completada com as coordenadas na primeira consulta de clima.
+func lookupCEP(ctx context.Context, clients upstreamClients, cep string, withCoordinates bool) (location cepLocation, hit bool, err error) {
+	if !isCEPAllowed(cep) {
+		return cepLocation{}, false, errCEPNotAllowed
+	}
+	if cacheDisabled || cepCacheTTL <= 0 {
+		location, err = getCityFromCEP(ctx, clients, cep, withCoordinates)
+		return location, false, err
+	}
+	if cached, _, ok := cepCache.get(cep); ok {
+		sources := cachedSources(cached.Sources)
+		if withCoordinates && cached.CoordinatesPending {
+			resolveCoordinates(ctx, clients, cep, &cached)
+			cepCache.set(cep, cached)
+			sources = append(sources, cached.Sources[len(sources):]...) // A BrasilAPI foi consultada agora
+		}
+		cached.Sources = sources
+		return cached, true, nil
+	}
+
+	location, err = getCityFromCEP(ctx, clients, cep, withCoordinates)
+	if err == nil {
+		cepCache.set(cep, location)
+	}
+	return location, false, err
+}
+
+// cacheStatus converte o resultado do cache no valor do cabeçalho X-Cache
+func cacheStatus(hit bool) string {
+	if hit {
+		return cacheHit
+	}
+	return cacheMiss
+}
+
+// weatherCacheKey normaliza a consulta para que variações de caixa compartilhem a mesma entrada
+func weatherCacheKey(query string) string {
+	return strings.ToLower(strings.TrimSpace(query))
+}
+
+// nextUpdateAt calcula quando a leitura deixa de ser fresca no cache, ou seja, a partir de
+// quando uma nova consulta pode trazer dados diferentes. Leituras antigas (stale) e caches
+// sem TTL não geram sugestão.
+func nextUpdateAt(weather *weatherReading) (time.Time, bool) {
+	if cacheDisabled || weatherCacheTTL <= 0 || weather.Stale || weather.FetchedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return weather.FetchedAt.Add(weatherCacheTTL).UTC().Truncate(time.Second), true
+}
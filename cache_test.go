@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTTLCache_Expiration(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := newTTLCache[string](func() time.Duration { return time.Minute })
+	cache.now = func() time.Time { return now }
+
+	cache.set("key", "value")
+
+	now = now.Add(30 * time.Second)
+	value, age, ok := cache.get("key")
+	if !ok || value != "value" || age != 30*time.Second {
+		t.Errorf("got (%q, %s, %v) want (\"value\", 30s, true)", value, age, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, ok := cache.get("key"); ok {
+		t.Error("expected entry to expire after maxAge")
+	}
+}
+
+func TestTTLCache_MaxEntries(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := newTTLCache[int](func() time.Duration { return time.Hour })
+	cache.now = func() time.Time { return now }
+
+	// Todas as entradas continuam frescas: o limite vale mesmo sem nada expirado
+	total := maxCacheEntries + 10
+	for i := 0; i < total; i++ {
+		now = now.Add(time.Millisecond)
+		cache.set(strconv.Itoa(i), i)
+	}
+
+	if size := len(cache.entries); size != maxCacheEntries {
+		t.Errorf("got %d entries want %d", size, maxCacheEntries)
+	}
+	for i := 0; i < total-maxCacheEntries; i++ {
+		if _, _, ok := cache.get(strconv.Itoa(i)); ok {
+			t.Errorf("expected the oldest entry %d to be evicted", i)
+		}
+	}
+	if value, _, ok := cache.get(strconv.Itoa(total - 1)); !ok || value != total-1 {
+		t.Errorf("expected the newest entry to be kept, got (%d, %v)", value, ok)
+	}
+
+	// Substituir uma entrada existente não remove outra
+	cache.set(strconv.Itoa(total-1), 0)
+	if _, _, ok := cache.get(strconv.Itoa(total - maxCacheEntries)); !ok {
+		t.Error("expected updating an existing key to keep the other entries")
+	}
+}
+
+func TestWeatherHandler_StaleWhileError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	now := time.Now()
+	weatherCache.now = func() time.Time { return now }
+	staleGracePeriod = 30 * time.Minute
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, req)
+		return rr
+	}
+
+	// Primeira requisição bem-sucedida popula o cache
+	if rr := doRequest(); rr.Code != http.StatusOK {
+		t.Fatalf("initial request: got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// A leitura passou do TTL, mas ainda está dentro da janela de tolerância
+	now = now.Add(10 * time.Minute)
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	rr := doRequest()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("stale request: got status %v want %v", rr.Code, http.StatusOK)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if !response.Stale || response.TempC != 18.5 {
+		t.Errorf("expected stale cached reading, got %+v", response)
+	}
+
+	// Fora da janela de tolerância a falha volta a ser propagada
+	now = now.Add(time.Hour)
+	if rr := doRequest(); rr.Code != http.StatusInternalServerError {
+		t.Errorf("expired request: got status %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestWeatherHandler_StaleWhileErrorWithoutCachedReading(t *testing.T) {
+	setup()
+	defer teardown()
+
+	staleGracePeriod = 30 * time.Minute
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	// Sem leitura anterior para a cidade, a falha da WeatherAPI é propagada
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+	if _, _, ok := weatherCache.get(weatherCacheKey("São Paulo")); ok {
+		t.Error("expected the failed reading not to be cached")
+	}
+}
+
+func TestWeatherHandler_StaleWhileErrorDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	staleGracePeriod = 0
+	now := time.Now()
+	weatherCache.now = func() time.Time { return now }
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+	weatherHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	now = now.Add(time.Second)
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestWeatherHandler_CacheDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cacheDisabled = true
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+
+	const requests = 3
+	for i := 0; i < requests; i++ {
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+
+	if calls := mockWeatherAPICalls.Load(); calls != requests {
+		t.Errorf("expected every request to hit WeatherAPI (%d calls), got %d", requests, calls)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != requests {
+		t.Errorf("expected every request to hit ViaCEP (%d calls), got %d", requests, calls)
+	}
+	if _, _, ok := weatherCache.get(weatherCacheKey("São Paulo")); ok {
+		t.Error("expected nothing to be stored in the weather cache")
+	}
+
+	// Sem cache, não há leitura antiga para servir quando a WeatherAPI falha
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestWeatherHandler_NextUpdateAt(t *testing.T) {
+	setup()
+	defer teardown()
+
+	observedAt := time.Date(2025, 4, 21, 12, 0, 0, 0, time.UTC)
+	weatherCache.now = func() time.Time { return observedAt }
+	weatherCacheTTL = 10 * time.Minute
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.NextUpdateAt == nil {
+		t.Fatal("expected next_update_at to be set")
+	}
+	if ahead := response.NextUpdateAt.Sub(observedAt); ahead < weatherCacheTTL-time.Second || ahead > weatherCacheTTL+time.Second {
+		t.Errorf("expected next_update_at roughly %s after the observation, got %s", weatherCacheTTL, ahead)
+	}
+}
+
+func TestWeatherHandler_NextUpdateAtOmitted(t *testing.T) {
+	testCases := []struct {
+		name      string
+		configure func()
+	}{
+		{name: "no TTL", configure: func() {}},
+		{name: "cache disabled", configure: func() {
+			weatherCacheTTL = 10 * time.Minute
+			cacheDisabled = true
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+			tc.configure()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			var body map[string]any
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if _, ok := body["next_update_at"]; ok {
+				t.Errorf("expected next_update_at to be omitted, got %v", body["next_update_at"])
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_XCacheHeader(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, req)
+		return rr
+	}
+
+	for i, expected := range []string{cacheMiss, cacheHit} {
+		rr := doRequest()
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+		if got := rr.Header().Get(cacheHeader); got != expected {
+			t.Errorf("request %d: got %s %q want %q", i+1, cacheHeader, got, expected)
+		}
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected 1 ViaCEP call, got %d", calls)
+	}
+
+	// Com o cache desativado toda requisição é MISS
+	cacheDisabled = true
+	if got := doRequest().Header().Get(cacheHeader); got != cacheMiss {
+		t.Errorf("disabled cache: got %s %q want %q", cacheHeader, got, cacheMiss)
+	}
+}
+
+func TestLookupCEP_DoesNotCacheFailures(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	mockViaCEPResponse = `{"erro": true}`
+
+	if _, hit, err := lookupCEP(context.Background(), defaultClients, "99999999", true); !errors.Is(err, errCannotFindZip) || hit {
+		t.Fatalf("got (hit=%v, %v) want not-found miss", hit, err)
+	}
+
+	mockViaCEPResponse = `{"localidade": "Curitiba"}`
+	location, hit, err := lookupCEP(context.Background(), defaultClients, "99999999", true)
+	if err != nil || hit || location.City != "Curitiba" {
+		t.Errorf("got (%+v, hit=%v, %v) want fresh Curitiba lookup", location, hit, err)
+	}
+}
+
+func TestGetWeatherForCity_FreshCache(t *testing.T) {
+	setup()
+	defer teardown()
+
+	now := time.Now()
+	weatherCache.now = func() time.Time { return now }
+	weatherCacheTTL = 10 * time.Minute
+	mockWeatherAPIResponse = `{"current": {"temp_c": 19.0}}`
+	location := cepLocation{City: "São Paulo", UF: "SP"}
+
+	lookup := func() *weatherReading {
+		t.Helper()
+		weather, err := getWeatherForCity(context.Background(), defaultClients, location, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return weather
+	}
+
+	lookup()
+	// Dentro do TTL a leitura vem do cache, mesmo que a WeatherAPI tenha mudado
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+	now = now.Add(9 * time.Minute)
+	if weather := lookup(); weather.Current.TempC != 19.0 || weather.Stale {
+		t.Errorf("expected fresh cached reading of 19.0, got %+v (stale=%v)", weather.Current, weather.Stale)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 1 {
+		t.Errorf("expected 1 WeatherAPI call within the TTL, got %d", calls)
+	}
+
+	// Expirado o TTL, a WeatherAPI volta a ser consultada
+	now = now.Add(2 * time.Minute)
+	if weather := lookup(); weather.Current.TempC != 25.0 {
+		t.Errorf("expected new reading of 25.0 after the TTL, got %v", weather.Current.TempC)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 2 {
+		t.Errorf("expected 2 WeatherAPI calls after the TTL, got %d", calls)
+	}
+
+	// Sem TTL, toda consulta vai à WeatherAPI
+	weatherCacheTTL = 0
+	lookup()
+	if calls := mockWeatherAPICalls.Load(); calls != 3 {
+		t.Errorf("expected 3 WeatherAPI calls without TTL, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_FreshCacheConcurrent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	weatherCacheTTL = 10 * time.Minute
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 19.0}}`
+
+	// A primeira requisição popula o cache; as concorrentes seguintes não consultam a WeatherAPI
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("initial request: got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+			if rr.Code != http.StatusOK {
+				t.Errorf("got status %v want %v", rr.Code, http.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := mockWeatherAPICalls.Load(); calls != 1 {
+		t.Errorf("expected 1 WeatherAPI call, got %d", calls)
+	}
+}
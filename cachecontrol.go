@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	responseCacheMaxAgeEnv = "RESPONSE_CACHE_MAX_AGE"
+
+	defaultResponseCacheMaxAge = 600 * time.Second
+)
+
+// responseCacheMaxAge é o max-age anunciado a navegadores e CDNs nas respostas de sucesso.
+// Zero omite o cabeçalho nessas respostas.
+var responseCacheMaxAge = defaultResponseCacheMaxAge
+
+// setCacheControl define o Cache-Control conforme o status: respostas de sucesso podem ser
+// guardadas por responseCacheMaxAge, enquanto erros nunca são armazenados, para que uma
+// falha passageira das APIs externas não fique presa no cache de um CDN
+func setCacheControl(w http.ResponseWriter, status int) {
+	switch {
+	case status >= http.StatusBadRequest:
+		w.Header().Set("Cache-Control", "no-store")
+	case responseCacheMaxAge > 0:
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(responseCacheMaxAge.Seconds())))
+	default:
+		w.Header().Del("Cache-Control")
+	}
+}
+
+// writeUncachedJSON envia um JSON que reflete o estado do processo no momento (ex: /stats,
+// /version logo após um deploy), com no-store para que navegadores e CDNs nunca o reaproveitem
+func writeUncachedJSON(ctx context.Context, w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Cache-Control", "no-store")
+	encodeJSON(ctx, w, status, body)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWeatherHandler_CacheControl(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "public, max-age=600" {
+		t.Errorf("expected the default max-age on a 200, got %q", got)
+	}
+
+	mockViaCEPResponse = `{"erro": true}`
+	rr = httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/99999999", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("expected no-store on a 404, got %q", got)
+	}
+}
+
+func TestSetCacheControl(t *testing.T) {
+	defer func() { responseCacheMaxAge = defaultResponseCacheMaxAge }()
+
+	testCases := []struct {
+		name     string
+		maxAge   int
+		status   int
+		expected string
+	}{
+		{"success", 60, http.StatusOK, "public, max-age=60"},
+		{"not modified", 60, http.StatusNotModified, "public, max-age=60"},
+		{"client error", 60, http.StatusUnprocessableEntity, "no-store"},
+		{"upstream error", 60, http.StatusServiceUnavailable, "no-store"},
+		{"disabled success", 0, http.StatusOK, ""},
+		{"disabled error", 0, http.StatusNotFound, "no-store"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			responseCacheMaxAge = time.Duration(tc.maxAge) * time.Second
+			rr := httptest.NewRecorder()
+			setCacheControl(rr, tc.status)
+			if got := rr.Header().Get("Cache-Control"); got != tc.expected {
+				t.Errorf("Cache-Control: got %q want %q", got, tc.expected)
+			}
+		})
+	}
+}
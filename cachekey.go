@@ -0,0 +1,24 @@
+package main
+
+import "net/http"
+
+const cacheKeyHeader = "X-Cache-Key"
+
+// readingCacheKey é a chave de uma leitura no cache de clima: a consulta normalizada da
+// WeatherAPI, com o sufixo "|aqi" quando a qualidade do ar é pedida. CEPs da mesma cidade
+// (ou com as mesmas coordenadas) compartilham a entrada.
+func readingCacheKey(query string, includeAirQuality bool) string {
+	key := weatherCacheKey(query)
+	if includeAirQuality {
+		key += "|aqi" // Leituras sem qualidade do ar não atendem pedidos com ?aqi=true
+	}
+	return key
+}
+
+// setCacheKeyHeader expõe, com DEBUG_ENDPOINTS, a chave de cache usada pela leitura. Leituras
+// que não passaram pelo cache da WeatherAPI (ex: apenas a Open-Meteo no consenso) não têm chave.
+func setCacheKeyHeader(w http.ResponseWriter, weather *weatherReading) {
+	if debugEndpoints && weather.CacheKey != "" {
+		w.Header().Set(cacheKeyHeader, weather.CacheKey)
+	}
+}
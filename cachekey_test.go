@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadingCacheKey(t *testing.T) {
+	testCases := []struct {
+		name              string
+		query             string
+		includeAirQuality bool
+		expected          string
+	}{
+		{"city and UF", "São Paulo,SP,Brazil", false, "são paulo,sp,brazil"},
+		{"coordinates", "-23.5505,-46.6333", false, "-23.5505,-46.6333"},
+		{"air quality", "São Paulo,SP,Brazil", true, "são paulo,sp,brazil|aqi"},
+		{"surrounding spaces", " Curitiba ", false, "curitiba"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if key := readingCacheKey(tc.query, tc.includeAirQuality); key != tc.expected {
+				t.Errorf("got %q want %q", key, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_CacheKeyHeader(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+	weatherCacheTTL = 10 * time.Minute
+
+	t.Run("hidden by default", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000?extended=true", nil)
+		rr := httptest.NewRecorder()
+
+		weatherHandler(rr, req)
+
+		if key := rr.Header().Get(cacheKeyHeader); key != "" {
+			t.Errorf("expected no %s header without DEBUG_ENDPOINTS, got %q", cacheKeyHeader, key)
+		}
+	})
+
+	t.Run("exposed with DEBUG_ENDPOINTS", func(t *testing.T) {
+		debugEndpoints = true
+
+		// CEPs da mesma cidade compartilham a leitura, e o cabeçalho mostra a mesma chave
+		for _, path := range []string{"/weather/01001000", "/weather/01310100?extended=true"} {
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+			expected := "são paulo,sp,brazil"
+			if key := rr.Header().Get(cacheKeyHeader); key != expected {
+				t.Errorf("%s: got %s %q want %q", path, cacheKeyHeader, key, expected)
+			}
+		}
+		if calls := mockWeatherAPICalls.Load(); calls != 1 {
+			t.Errorf("expected every CEP to be served from the shared entry, got %d WeatherAPI calls", calls)
+		}
+
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?aqi=true", nil))
+		if key, expected := rr.Header().Get(cacheKeyHeader), "são paulo,sp,brazil|aqi"; key != expected {
+			t.Errorf("got %s %q want %q", cacheKeyHeader, key, expected)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	cepDBPathEnv        = "CEP_DB_PATH"
+	cepDBFallthroughEnv = "CEP_DB_FALLTHROUGH"
+)
+
+var (
+	// localCEPDB é a base offline consultada antes dos provedores de rede; nil quando não configurada
+	localCEPDB *cepDatabase
+	// cepDBFallthrough define se um CEP ausente na base local ainda é buscado na rede
+	cepDBFallthrough = true
+)
+
+// cepDatabase é uma base de CEPs carregada em memória a partir de um arquivo CSV,
+// para implantações offline ou isoladas da internet
+type cepDatabase struct {
+	entries map[string]cepLocation
+}
+
+// loadCEPDatabase carrega a base a partir de um CSV com as colunas cep,city,uf e,
+// opcionalmente, lat,lon. Uma linha de cabeçalho iniciada por "cep" é ignorada.
+// Ex: 01001000,São Paulo,SP,-23.5505,-46.6333
+func loadCEPDatabase(path string) (*cepDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CEP database: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // As colunas de coordenadas são opcionais
+	reader.TrimLeadingSpace = true
+
+	db := &cepDatabase{entries: make(map[string]cepLocation)}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CEP database: %w", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "cep") {
+			continue
+		}
+
+		cep, location, err := parseCEPRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CEP database entry at line %d: %w", line, err)
+		}
+		db.entries[cep] = location
+	}
+
+	return db, nil
+}
+
+// parseCEPRecord converte uma linha do CSV em CEP e localização
+func parseCEPRecord(record []string) (string, cepLocation, error) {
+	if len(record) != 3 && len(record) != 5 {
+		return "", cepLocation{}, fmt.Errorf("expected 3 or 5 columns, got %d", len(record))
+	}
+
+	cep := strings.ReplaceAll(strings.TrimSpace(record[0]), "-", "")
+	if !isValidCEP(cep) {
+		return "", cepLocation{}, fmt.Errorf("invalid CEP %q", record[0])
+	}
+
+	location := cepLocation{City: strings.TrimSpace(record[1]), UF: strings.TrimSpace(record[2])}
+	if location.City == "" {
+		return "", cepLocation{}, fmt.Errorf("missing city for CEP %s", cep)
+	}
+
+	if len(record) == 5 {
+		lat, errLat := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		lon, errLon := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		if errLat != nil || errLon != nil {
+			return "", cepLocation{}, fmt.Errorf("invalid coordinates for CEP %s", cep)
+		}
+		location.Coordinates = &coordinates{Lat: lat, Lon: lon}
+	}
+
+	return cep, location, nil
+}
+
+// lookup busca um CEP na base local
+func (db *cepDatabase) lookup(cep string) (cepLocation, bool) {
+	location, ok := db.entries[cep]
+	return location, ok
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCEPDatabase cria um arquivo CSV temporário com o conteúdo informado
+func writeCEPDatabase(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ceps.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write CEP database: %v", err)
+	}
+	return path
+}
+
+func TestLoadCEPDatabase(t *testing.T) {
+	path := writeCEPDatabase(t, "cep,city,uf,lat,lon\n01001-000,São Paulo,SP,-23.5505,-46.6333\n69900000,Rio Branco,AC\n")
+
+	db, err := loadCEPDatabase(path)
+	if err != nil {
+		t.Fatalf("failed to load database: %v", err)
+	}
+
+	location, ok := db.lookup("01001000")
+	if !ok || location.City != "São Paulo" || location.UF != "SP" || location.Coordinates == nil || location.Coordinates.Lat != -23.5505 {
+		t.Errorf("unexpected entry with coordinates: %+v", location)
+	}
+	location, ok = db.lookup("69900000")
+	if !ok || location.City != "Rio Branco" || location.Coordinates != nil {
+		t.Errorf("unexpected entry without coordinates: %+v", location)
+	}
+	if _, ok := db.lookup("99999999"); ok {
+		t.Error("expected miss for unknown CEP")
+	}
+}
+
+func TestLoadCEPDatabase_InvalidEntries(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid CEP":         "123,São Paulo,SP\n",
+		"missing city":        "01001000,,SP\n",
+		"wrong column count":  "01001000,São Paulo\n",
+		"invalid coordinates": "01001000,São Paulo,SP,abc,-46.6\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadCEPDatabase(writeCEPDatabase(t, content)); err == nil {
+				t.Error("expected error for invalid entry")
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_LocalCEPDatabase(t *testing.T) {
+	setup()
+	defer teardown()
+
+	db, err := loadCEPDatabase(writeCEPDatabase(t, "69900000,Rio Branco,AC\n"))
+	if err != nil {
+		t.Fatalf("failed to load database: %v", err)
+	}
+	localCEPDB = db
+
+	mockWeatherAPIResponse = `{"current": {"temp_c": 30.0}}`
+	expectWeatherAPICity = "Rio Branco,AC,Brazil"
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/69900000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockViaCEPCalls.Load() + mockBrasilAPICalls.Load(); calls != 0 {
+		t.Errorf("expected no CEP network calls for a local hit, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_LocalCEPDatabaseMiss(t *testing.T) {
+	setup()
+	defer teardown()
+
+	db, err := loadCEPDatabase(writeCEPDatabase(t, "69900000,Rio Branco,AC\n"))
+	if err != nil {
+		t.Fatalf("failed to load database: %v", err)
+	}
+	localCEPDB = db
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	t.Run("falls through to network", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+		if rr.Code != http.StatusOK || mockViaCEPCalls.Load() != 1 {
+			t.Errorf("got status %v with %d ViaCEP calls, want 200 with 1 call", rr.Code, mockViaCEPCalls.Load())
+		}
+	})
+
+	t.Run("offline only", func(t *testing.T) {
+		cepDBFallthrough = false
+		mockViaCEPCalls.Store(0)
+
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+		if rr.Code != http.StatusNotFound || mockViaCEPCalls.Load() != 0 {
+			t.Errorf("got status %v with %d ViaCEP calls, want 404 with no calls", rr.Code, mockViaCEPCalls.Load())
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const cepFallbackEnv = "CEP_FALLBACK"
+
+// Estratégias para CEPs válidos cuja localidade o ViaCEP retorna vazia
+const (
+	cepFallbackNone         = "none"
+	cepFallbackNearest      = "nearest"       // Consulta pelas coordenadas do CEP (estação mais próxima)
+	cepFallbackStateCapital = "state-capital" // Consulta pela capital da UF
+)
+
+// cepFallback é a estratégia configurada; "none" mantém o 404
+var cepFallback = cepFallbackNone
+
+// stateCapitals mapeia cada UF para a sua capital
+var stateCapitals = map[string]string{
+	"AC": "Rio Branco",
+	"AL": "Maceió",
+	"AP": "Macapá",
+	"AM": "Manaus",
+	"BA": "Salvador",
+	"CE": "Fortaleza",
+	"DF": "Brasília",
+	"ES": "Vitória",
+	"GO": "Goiânia",
+	"MA": "São Luís",
+	"MT": "Cuiabá",
+	"MS": "Campo Grande",
+	"MG": "Belo Horizonte",
+	"PA": "Belém",
+	"PB": "João Pessoa",
+	"PR": "Curitiba",
+	"PE": "Recife",
+	"PI": "Teresina",
+	"RJ": "Rio de Janeiro",
+	"RN": "Natal",
+	"RS": "Porto Alegre",
+	"RO": "Porto Velho",
+	"RR": "Boa Vista",
+	"SC": "Florianópolis",
+	"SP": "São Paulo",
+	"SE": "Aracaju",
+	"TO": "Palmas",
+}
+
+// parseCEPFallback valida a estratégia de CEP_FALLBACK; valores desconhecidos desativam o fallback
+func parseCEPFallback(raw string) (string, bool) {
+	switch strategy := strings.ToLower(strings.TrimSpace(raw)); strategy {
+	case "", cepFallbackNone:
+		return cepFallbackNone, true
+	case cepFallbackNearest, cepFallbackStateCapital:
+		return strategy, true
+	default:
+		return cepFallbackNone, false
+	}
+}
+
+// fallbackLocation aproxima a localização de um CEP válido sem localidade no ViaCEP, conforme
+// a estratégia configurada. O resultado é marcado como aproximado.
+func fallbackLocation(ctx context.Context, clients upstreamClients, cep, uf string) (cepLocation, bool) {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	switch cepFallback {
+	case cepFallbackStateCapital:
+		capital, ok := stateCapitals[uf]
+		if !ok {
+			return cepLocation{}, false
+		}
+		slog.WarnContext(ctx, "CEP without locality, using the state capital", "cep", cep, "uf", uf, "city", capital)
+		return cepLocation{City: capital, UF: uf, Approximate: true}, true
+	case cepFallbackNearest:
+		coords, err := getCoordinatesFromCEP(ctx, clients.BrasilAPI, cep)
+		if err != nil || coords == nil {
+			slog.WarnContext(ctx, "CEP without locality and coordinates, no fallback available", "cep", cep, "error", err)
+			return cepLocation{}, false
+		}
+		slog.WarnContext(ctx, "CEP without locality, using the nearest weather station", "cep", cep, "coordinates", coords.String())
+		return cepLocation{UF: uf, Coordinates: coords, Approximate: true, Sources: []string{sourceBrasilAPI}}, true
+	default:
+		return cepLocation{}, false
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCEPFallback(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected string
+		ok       bool
+	}{
+		{"", cepFallbackNone, true},
+		{"none", cepFallbackNone, true},
+		{" State-Capital ", cepFallbackStateCapital, true},
+		{"nearest", cepFallbackNearest, true},
+		{"closest", cepFallbackNone, false},
+	}
+
+	for _, tc := range testCases {
+		strategy, ok := parseCEPFallback(tc.raw)
+		if strategy != tc.expected || ok != tc.ok {
+			t.Errorf("parseCEPFallback(%q) = (%q, %t), want (%q, %t)", tc.raw, strategy, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestWeatherHandler_StateCapitalFallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepFallback = cepFallbackStateCapital
+	mockViaCEPResponse = `{"localidade": "", "uf": "mg"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 23.0}}`
+	expectWeatherAPICity = "Belo Horizonte,MG,Brazil"
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/35500000", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if !response.Approximate || response.TempC != 23.0 {
+		t.Errorf("expected an approximate reading from the state capital, got %+v", response)
+	}
+}
+
+func TestWeatherHandler_CEPFallbackNotApplied(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy string
+		viaCEP   string
+	}{
+		{"fallback disabled", cepFallbackNone, `{"localidade": "", "uf": "MG"}`},
+		{"unknown UF", cepFallbackStateCapital, `{"localidade": "", "uf": "XX"}`},
+		{"CEP does not exist", cepFallbackStateCapital, `{"erro": true}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			cepFallback = tc.strategy
+			mockViaCEPResponse = tc.viaCEP
+			mockWeatherAPIResponse = `{"current": {"temp_c": 23.0}}`
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/35500000", nil))
+
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected no WeatherAPI call, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_NearestFallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepFallback = cepFallbackNearest
+	mockViaCEPResponse = `{"localidade": "", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"cep": "01001000", "location": {"type": "Point", "coordinates": {"longitude": "-46.6333", "latitude": "-23.5505"}}}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+	expectWeatherAPICity = "-23.5505,-46.6333"
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if !response.Approximate {
+		t.Errorf("expected an approximate reading, got %+v", response)
+	}
+}
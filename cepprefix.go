@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	allowedCEPPrefixesEnv = "ALLOWED_CEP_PREFIXES"
+	deniedCEPPrefixesEnv  = "DENIED_CEP_PREFIXES"
+)
+
+// errCEPNotAllowed indica um CEP fora das faixas atendidas por esta instalação
+var errCEPNotAllowed = errors.New(errorCEPNotAllowed)
+
+// Faixas de CEP atendidas, por prefixo (ex: "01" cobre 01000-000 a 01999-999). Sem
+// allowedCEPPrefixes todos os CEPs são atendidos; deniedCEPPrefixes prevalece sobre ela.
+var (
+	allowedCEPPrefixes []string
+	deniedCEPPrefixes  []string
+)
+
+// parseCEPPrefixes lê uma lista de prefixos separados por vírgula. Uma entrada que não é
+// dígito (ou mais longa que um CEP) é um erro: ignorá-la poderia esvaziar a lista e liberar
+// todos os CEPs (ex: "0l" no lugar de "01").
+func parseCEPPrefixes(raw string) ([]string, error) {
+	var prefixes []string
+	for _, entry := range strings.Split(raw, ",") {
+		prefix := strings.TrimSpace(entry)
+		if prefix == "" {
+			continue
+		}
+		if len(prefix) > 8 || strings.Trim(prefix, "0123456789") != "" {
+			return nil, fmt.Errorf("invalid CEP prefix %q", prefix)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// isCEPAllowed aplica as listas de prefixos a um CEP já normalizado
+func isCEPAllowed(cep string) bool {
+	if hasCEPPrefix(cep, deniedCEPPrefixes) {
+		return false
+	}
+	return len(allowedCEPPrefixes) == 0 || hasCEPPrefix(cep, allowedCEPPrefixes)
+}
+
+// hasCEPPrefix informa se o CEP começa por algum dos prefixos
+func hasCEPPrefix(cep string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(cep, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCEPPrefixes(t *testing.T) {
+	got, err := parseCEPPrefixes(" 01, 02 ,,20")
+	if want := []string{"01", "02", "20"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCEPPrefixes() = %v (%v), want %v", got, err, want)
+	}
+	if got, err := parseCEPPrefixes(""); err != nil || got != nil {
+		t.Errorf("expected no prefixes for an empty value, got %v (%v)", got, err)
+	}
+
+	// Uma lista só com entradas inválidas não pode virar uma lista vazia, que liberaria tudo
+	for _, raw := range []string{"0l", "01,2a", "123456789", "01, -2"} {
+		if got, err := parseCEPPrefixes(raw); err == nil {
+			t.Errorf("%q: expected an error, got %v", raw, got)
+		}
+	}
+}
+
+func TestWeatherHandler_CEPPrefixes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		allowed        []string
+		denied         []string
+		cep            string
+		expectedStatus int
+	}{
+		{"allowed prefix", []string{"01", "20"}, nil, "01001000", http.StatusOK},
+		{"denied by allowlist", []string{"01", "20"}, nil, "30140071", http.StatusForbidden},
+		{"unset allows all", nil, nil, "30140071", http.StatusOK},
+		{"denylist", nil, []string{"30"}, "30140071", http.StatusForbidden},
+		{"denylist overrides allowlist", []string{"0"}, []string{"0100"}, "01001000", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			allowedCEPPrefixes = tc.allowed
+			deniedCEPPrefixes = tc.denied
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/"+tc.cep, nil))
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("got status %v want %v", rr.Code, tc.expectedStatus)
+			}
+			if tc.expectedStatus != http.StatusForbidden {
+				return
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorCEPNotAllowed {
+				t.Errorf("got body '%s' want '%s'", body, errorCEPNotAllowed)
+			}
+			if calls := mockViaCEPCalls.Load() + mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected no upstream calls for a CEP outside the allowed prefixes, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestMultiCEPHandler_CEPPrefixes(t *testing.T) {
+	setup()
+	defer teardown()
+
+	allowedCEPPrefixes = []string{"01"}
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000,30140071", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, `{"cep":"30140071","status":"error","error":"`+errorCEPNotAllowed+`"}`) {
+		t.Errorf("expected the denied CEP to be reported as an error in the batch, got %s", body)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected only the allowed CEP to reach ViaCEP, got %d calls", calls)
+	}
+}
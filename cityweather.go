@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cityWeatherHandler atende GET /weather/city/{nome}, para quem já conhece a cidade e não
+// tem um CEP: o ViaCEP não é consultado e a WeatherAPI recebe o nome como informado
+// (já decodificado da URL, ex: "S%C3%A3o%20Paulo" -> "São Paulo"). A resposta e os
+// parâmetros de query são os mesmos de /weather/{cep}.
+func cityWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	name := strings.TrimSpace(strings.TrimSuffix(r.PathValue("name"), "/"))
+	if name == "" {
+		writeError(w, r, http.StatusUnprocessableEntity, errorEmptyCityName) // 422
+		return
+	}
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("city", name))
+
+	opts, err := parseResponseOptions(r)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+		return
+	}
+
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	weatherAPIStart := time.Now()
+	weather, consensus, err := getWeather(ctx, defaultClients, cepLocation{City: name}, opts)
+	weatherAPIDuration := time.Since(weatherAPIStart)
+	if err != nil {
+		// O código 1006 da WeatherAPI indica uma cidade desconhecida
+		if errors.Is(err, errCannotFindZip) {
+			writeError(w, r, http.StatusNotFound, errorCannotFindCity) // 404
+			return
+		}
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting weather for city", "city", name, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	response := newWeatherResponse(weather, opts)
+	response.Consensus = consensus
+	if opts.Timing {
+		response.Timings = newTimingsResponse(0, weatherAPIDuration)
+	}
+	if opts.Extended {
+		response.Sources = responseSources(cepLocation{}, weather)
+	}
+	slog.InfoContext(ctx, "Weather request served", "city", name, "status", http.StatusOK, "stale", weather.Stale, "degraded", weather.Degraded, "latency", time.Since(start))
+	setDegradedHeader(w, weather)
+
+	writeWeatherResponse(w, r, name, response, opts) // 200
+}
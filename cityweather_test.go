@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWeatherHandler_CityName(t *testing.T) {
+	setup()
+	defer teardown()
+
+	expectWeatherAPICity = "São Paulo"
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/city/S%C3%A3o%20Paulo", nil)
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected ViaCEP to be skipped, got %d calls", calls)
+	}
+
+	var actualResponse WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	expectedResponse := WeatherResponse{
+		TempC: 25.5,
+		TempF: celsiusToFahrenheit(25.5),
+		TempK: celsiusToKelvin(25.5),
+	}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("handler returned unexpected body: got %+v want %+v", actualResponse, expectedResponse)
+	}
+}
+
+func TestWeatherHandler_CityNameErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"unknown city", "/weather/city/Atlantida", http.StatusNotFound, errorCannotFindCity},
+		{"empty name", "/weather/city/", http.StatusUnprocessableEntity, errorEmptyCityName},
+		{"blank name", "/weather/city/%20%20", http.StatusUnprocessableEntity, errorEmptyCityName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockWeatherAPIStatusCode = http.StatusBadRequest
+			mockWeatherAPIResponse = `{"error": {"code": 1006, "message": "No matching location found."}}`
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.expectedCode)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, tc.expectedBody)
+			}
+		})
+	}
+}
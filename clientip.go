@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const (
+	forwardedSkipPrivateEnv = "FORWARDED_SKIP_PRIVATE"
+	trustedProxiesEnv       = "TRUSTED_PROXIES"
+)
+
+// forwardedSkipPrivate faz o clientIP pular, no X-Forwarded-For, endereços privados ou de
+// loopback (ex: proxies internos acrescentados à cadeia) ao procurar o cliente
+var forwardedSkipPrivate = true
+
+// trustedProxies são as redes dos proxies cujo X-Forwarded-For é confiável. Vazio não confia
+// em nenhuma conexão: o cabeçalho é ignorado e vale o RemoteAddr.
+var trustedProxies []netip.Prefix
+
+// clientIP identifica o IP do cliente, usado no rate limit e nos logs. Atrás de um proxy
+// confiável (TRUSTED_PROXIES, ex: o balanceador do Cloud Run) lê o X-Forwarded-For; de
+// qualquer outra conexão o cabeçalho é ignorado, já que o cliente pode forjá-lo. Sem
+// cabeçalho (ou sem entradas válidas), usa o RemoteAddr.
+//
+// IPv4 e IPv6, com ou sem colchetes e porta, são aceitos e retornados na forma canônica
+// (ex: "2001:db8::1").
+func clientIP(r *http.Request) string {
+	peer, peerOK := parseIP(r.RemoteAddr)
+	if isTrustedProxy(peer, peerOK) {
+		if ip, ok := forwardedClientIP(r.Header.Values("X-Forwarded-For")); ok {
+			return ip.String()
+		}
+	}
+	if peerOK {
+		return peer.String()
+	}
+	return r.RemoteAddr
+}
+
+// isTrustedProxy indica se o X-Forwarded-For da conexão pode ser lido: apenas um par
+// (RemoteAddr) válido dentro de uma das redes de TRUSTED_PROXIES. Sem a lista, nenhum é.
+func isTrustedProxy(peer netip.Addr, ok bool) bool {
+	if !ok {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies lê uma lista de redes separadas por vírgula, em CIDR ("10.0.0.0/8",
+// "2001:db8::/32") ou endereços avulsos ("192.0.2.10", tratados como /32 ou /128). Ao
+// contrário de outros filtros, uma entrada inválida é um erro: ignorá-la abriria ou fecharia
+// a confiança no cabeçalho sem aviso claro.
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			network, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", entry, err)
+			}
+			networks = append(networks, network.Masked())
+			continue
+		}
+		ip, ok := parseIP(entry)
+		if !ok {
+			return nil, fmt.Errorf("invalid address %q", entry)
+		}
+		networks = append(networks, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return networks, nil
+}
+
+// forwardedClientIP escolhe o cliente na cadeia do X-Forwarded-For (possivelmente repartida
+// em vários cabeçalhos). Cada proxy acrescenta à direita o endereço de quem o chamou, então a
+// cadeia é percorrida da direita para a esquerda, pulando os proxies confiáveis (e, com
+// forwardedSkipPrivate, os endereços privados); o primeiro endereço restante é o cliente.
+// Entradas mais à esquerda podem ter sido forjadas por ele e não são consideradas. Se todas
+// forem puladas, retorna a mais à esquerda.
+func forwardedClientIP(headers []string) (netip.Addr, bool) {
+	var chain []netip.Addr
+	for _, header := range headers {
+		for _, entry := range strings.Split(header, ",") {
+			if ip, ok := parseIP(entry); ok {
+				chain = append(chain, ip)
+			}
+		}
+	}
+	if len(chain) == 0 {
+		return netip.Addr{}, false
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := chain[i]
+		if isTrustedProxy(ip, true) || (forwardedSkipPrivate && isPrivateIP(ip)) {
+			continue
+		}
+		return ip, true
+	}
+	return chain[0], true
+}
+
+// parseIP interpreta um endereço nos formatos "1.2.3.4", "1.2.3.4:80", "2001:db8::1" ou
+// "[2001:db8::1]:80". Endereços IPv4 mapeados em IPv6 (::ffff:1.2.3.4) viram IPv4.
+func parseIP(raw string) (netip.Addr, bool) {
+	raw = strings.Trim(strings.TrimSpace(raw), `"`)
+	if host, _, err := net.SplitHostPort(raw); err == nil {
+		raw = host
+	}
+	ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// isPrivateIP indica endereços que não identificam um cliente na internet (redes privadas,
+// loopback, link-local e não especificado)
+func isPrivateIP(ip netip.Addr) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
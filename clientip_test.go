@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		skipPrivate  bool
+		expected     string
+	}{
+		{"remote addr", "192.0.2.1:1234", nil, true, "192.0.2.1"},
+		{"remote addr without port", "192.0.2.1", nil, true, "192.0.2.1"},
+		{"bracketed IPv6 with port", "[2001:db8::1]:443", nil, true, "2001:db8::1"},
+		{"IPv6 without port", "2001:DB8::1", nil, true, "2001:db8::1"},
+		{"IPv4-mapped IPv6", "[::ffff:192.0.2.1]:80", nil, true, "192.0.2.1"},
+		{"forwarded for", "10.0.0.1:1234", []string{"203.0.113.7, 10.0.0.1"}, true, "203.0.113.7"},
+		{"multi-hop skips private entries", "10.0.0.1:1234", []string{"10.1.2.3, 192.168.0.9, 203.0.113.7, 10.0.0.1"}, true, "203.0.113.7"},
+		{"spoofed left-most entry ignored", "10.0.0.1:1234", []string{"1.2.3.4, 203.0.113.7, 10.0.0.1"}, true, "203.0.113.7"},
+		{"private hop kept when configured", "10.0.0.1:1234", []string{"203.0.113.7, 10.1.2.3"}, false, "10.1.2.3"},
+		{"forwarded IPv6 with brackets and port", "10.0.0.1:1234", []string{"[2001:db8::7]:51000, 10.0.0.1"}, true, "2001:db8::7"},
+		{"forwarded chain split across headers", "10.0.0.1:1234", []string{"127.0.0.1", "198.51.100.4"}, true, "198.51.100.4"},
+		{"only private entries", "10.0.0.1:1234", []string{"10.1.2.3, 172.16.0.1"}, true, "10.1.2.3"},
+		{"invalid entries ignored", "10.0.0.1:1234", []string{"unknown, 198.51.100.4"}, true, "198.51.100.4"},
+		{"garbage header falls back to remote addr", "192.0.2.1:1234", []string{"not-an-ip"}, true, "192.0.2.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(previous bool) { forwardedSkipPrivate = previous }(forwardedSkipPrivate)
+			defer func(previous []netip.Prefix) { trustedProxies = previous }(trustedProxies)
+			forwardedSkipPrivate = tc.skipPrivate
+			trustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if ip := clientIP(req); ip != tc.expected {
+				t.Errorf("got %q want %q", ip, tc.expected)
+			}
+		})
+	}
+}
+
+func TestClientIP_WithoutTrustedProxies(t *testing.T) {
+	defer func(previous []netip.Prefix) { trustedProxies = previous }(trustedProxies)
+	trustedProxies = nil
+
+	// Sem TRUSTED_PROXIES, nenhuma conexão é um proxy: o cabeçalho forjado é ignorado
+	for _, remoteAddr := range []string{"192.0.2.1:1234", "10.0.0.1:1234"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+		expected, _ := parseIP(remoteAddr)
+		if ip := clientIP(req); ip != expected.String() {
+			t.Errorf("%s: got %q want %q", remoteAddr, ip, expected)
+		}
+	}
+}
+
+func TestClientIP_TrustedProxies(t *testing.T) {
+	networks, err := parseTrustedProxies("10.0.0.0/8, 2001:db8::/32")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{"trusted proxy", "10.1.2.3:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"trusted IPv6 proxy", "[2001:db8::1]:443", []string{"198.51.100.4"}, "198.51.100.4"},
+		{"untrusted peer with spoofed header", "192.0.2.1:1234", []string{"203.0.113.7"}, "192.0.2.1"},
+		{"no proxy", "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"trusted proxy without header", "10.1.2.3:1234", nil, "10.1.2.3"},
+		{"chain through trusted proxies", "10.1.2.3:1234", []string{"198.51.100.4, 203.0.113.7, 10.9.9.9", "2001:db8::5"}, "203.0.113.7"},
+		{"only trusted proxies in chain", "10.1.2.3:1234", []string{"10.9.9.9, 10.0.0.2"}, "10.9.9.9"},
+		{"unparseable peer", "not-an-ip", []string{"203.0.113.7"}, "not-an-ip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(previous []netip.Prefix) { trustedProxies = previous }(trustedProxies)
+			trustedProxies = networks
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if ip := clientIP(req); ip != tc.expected {
+				t.Errorf("got %q want %q", ip, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := parseTrustedProxies(" 10.0.0.1/8 ,192.0.2.10,, [2001:db8::1]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.10/32"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}
+	if !slices.Equal(networks, expected) {
+		t.Errorf("got %v want %v", networks, expected)
+	}
+
+	for _, raw := range []string{"10.0.0.0/33", "proxy.internal", "10.0.0.0/8,nope"} {
+		if _, err := parseTrustedProxies(raw); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestRateLimit_IgnoresSpoofedForwardedFor(t *testing.T) {
+	testCases := map[string][]netip.Prefix{
+		"without trusted proxies": nil,
+		"untrusted peer":          {netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	for name, networks := range testCases {
+		t.Run(name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			trustedProxies = networks
+			clientRateLimiter = newRateLimiter(0.001, 1)
+			router := newRouter()
+
+			// Um cliente fora dos proxies confiáveis não escapa do limite trocando o X-Forwarded-For
+			codes := make([]int, 2)
+			for i, spoofed := range []string{"203.0.113.1", "203.0.113.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/version", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("X-Forwarded-For", spoofed)
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				codes[i] = rr.Code
+			}
+			if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+				t.Errorf("expected the second request to be limited, got %v", codes)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	viaCEPTimeoutEnv     = "VIACEP_TIMEOUT"
+	weatherAPITimeoutEnv = "WEATHERAPI_TIMEOUT"
+)
+
+// Prazos de cada chamada ao ViaCEP e à WeatherAPI, derivados do contexto da requisição.
+// Como os provedores têm latências bem diferentes, cada um tem o seu; o prazo total da
+// requisição (TOTAL_REQUEST_BUDGET) e o timeout do cliente continuam valendo.
+var (
+	viaCEPTimeout     = requestTimeout
+	weatherAPITimeout = requestTimeout
+)
+
+// upstreamClients reúne o cliente HTTP de cada provedor externo. As funções de consulta
+// recebem os clientes por parâmetro, permitindo transportes, timeouts ou proxies diferentes
+// por provedor (e clientes de teste) sem alterar o estado global.
+type upstreamClients struct {
+	ViaCEP     *http.Client
+	BrasilAPI  *http.Client
+	WeatherAPI *http.Client
+	OpenMeteo  *http.Client
+}
+
+// defaultClients são os clientes usados pelos handlers, configurados no main
+var defaultClients upstreamClients
+
+// withUpstreamTimeout deriva o contexto de uma chamada externa com o prazo do provedor;
+// zero mantém apenas o prazo já presente no contexto
+func withUpstreamTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// newUpstreamClients usa o mesmo cliente para todos os provedores
+func newUpstreamClients(client *http.Client) upstreamClients {
+	return upstreamClients{ViaCEP: client, BrasilAPI: client, WeatherAPI: client, OpenMeteo: client}
+}
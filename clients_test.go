@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc permite montar um transporte HTTP a partir de uma função
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// countingClient devolve um cliente que repassa as chamadas ao servidor mock contando-as
+func countingClient(calls *atomic.Int32) *http.Client {
+	base := mockServer.Client().Transport
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return base.RoundTrip(req)
+	})}
+}
+
+// cannedClient devolve um cliente que responde sempre o mesmo corpo, sem acessar a rede
+func cannedClient(status int, body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+func TestGetCityFromCEP_UsesGivenClients(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+
+	var viaCEPCalls, weatherAPICalls atomic.Int32
+	clients := upstreamClients{
+		ViaCEP:     countingClient(&viaCEPCalls),
+		BrasilAPI:  defaultClients.BrasilAPI,
+		WeatherAPI: countingClient(&weatherAPICalls),
+	}
+
+	location, err := getCityFromCEP(context.Background(), clients, "01001000", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if location.City != "São Paulo" {
+		t.Errorf("expected city 'São Paulo', got %q", location.City)
+	}
+	if viaCEPCalls.Load() != 1 {
+		t.Errorf("expected the ViaCEP client to be called once, got %d", viaCEPCalls.Load())
+	}
+	if weatherAPICalls.Load() != 0 {
+		t.Errorf("expected the WeatherAPI client to be unused, got %d calls", weatherAPICalls.Load())
+	}
+}
+
+func TestGetCityFromCEP_ClientError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	failing := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+	clients := newUpstreamClients(defaultClients.ViaCEP)
+	clients.ViaCEP = failing
+
+	if _, err := getCityFromCEP(context.Background(), clients, "01001000", true); err == nil {
+		t.Fatal("expected an error from the failing ViaCEP client")
+	}
+	if mockViaCEPCalls.Load() != 0 {
+		t.Errorf("expected the default client to be bypassed, got %d mock calls", mockViaCEPCalls.Load())
+	}
+}
+
+func TestGetWeatherForCity_CustomClient(t *testing.T) {
+	setup()
+	defer teardown()
+
+	clients := newUpstreamClients(cannedClient(http.StatusOK, `{"current": {"temp_c": 18.5}}`))
+
+	weather, err := getWeatherForCity(context.Background(), clients, cepLocation{City: "Curitiba"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weather.Current.TempC != 18.5 {
+		t.Errorf("expected temp_c 18.5 from the custom client, got %v", weather.Current.TempC)
+	}
+	if mockWeatherAPICalls.Load() != 0 {
+		t.Errorf("expected the mock server to be bypassed, got %d calls", mockWeatherAPICalls.Load())
+	}
+}
+
+func TestWeatherHandler_PerUpstreamTimeouts(t *testing.T) {
+	testCases := []struct {
+		name              string
+		viaCEPDelay       time.Duration
+		weatherAPIDelay   time.Duration
+		viaCEPTimeout     time.Duration
+		weatherAPITimeout time.Duration
+		expectedStatus    int
+		expectWeatherCall bool
+	}{
+		// O ViaCEP lento estoura o próprio prazo, sem chegar à WeatherAPI
+		{"viacep deadline", 200 * time.Millisecond, 0, 50 * time.Millisecond, time.Second, http.StatusGatewayTimeout, false},
+		// A WeatherAPI lenta estoura o próprio prazo, mesmo com o ViaCEP respondendo a tempo
+		{"weatherapi deadline", 0, 200 * time.Millisecond, time.Second, 50 * time.Millisecond, http.StatusGatewayTimeout, true},
+		// Um ViaCEP mais lento que o prazo da WeatherAPI não é afetado por ele
+		{"independent deadlines", 100 * time.Millisecond, 0, time.Second, 50 * time.Millisecond, http.StatusOK, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+			mockViaCEPDelay = tc.viaCEPDelay
+			mockWeatherAPIDelay = tc.weatherAPIDelay
+			viaCEPTimeout = tc.viaCEPTimeout
+			weatherAPITimeout = tc.weatherAPITimeout
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("got status %v want %v (body %s)", rr.Code, tc.expectedStatus, rr.Body.String())
+			}
+			if called := mockWeatherAPICalls.Load() > 0; called != tc.expectWeatherCall {
+				t.Errorf("WeatherAPI called: got %v want %v", called, tc.expectWeatherCall)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// errInvalidTimeout indica um ?timeout= que não é uma duração positiva
+	errInvalidTimeout = errors.New(errorInvalidTimeout)
+	// errClientTimeout é a causa do cancelamento quando o prazo do ?timeout= se esgota. Envolve
+	// context.DeadlineExceeded para que a resposta continue sendo 504.
+	errClientTimeout = fmt.Errorf("client timeout exceeded: %w", context.DeadlineExceeded)
+)
+
+// clientTimeoutLimit é o maior ?timeout= aceito: o próprio TOTAL_REQUEST_BUDGET, que o
+// cliente pode encurtar, mas nunca estender. Com o orçamento desativado, vale o padrão.
+func clientTimeoutLimit() time.Duration {
+	if totalRequestBudget <= 0 {
+		return defaultTotalRequestBudget
+	}
+	return totalRequestBudget
+}
+
+// parseClientTimeout interpreta o ?timeout= (ex: "3s", "500ms"). Vazio significa sem
+// override; durações inválidas, não positivas ou acima do limite são recusadas.
+func parseClientTimeout(raw string, limit time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		return 0, errInvalidTimeout
+	}
+	if timeout > limit {
+		return 0, fmt.Errorf("timeout must not exceed %s", limit)
+	}
+	return timeout, nil
+}
+
+// withClientTimeout aplica o ?timeout= ao contexto da requisição antes do roteamento. Como o
+// prazo de upstreamContext é derivado dele, o menor dos dois vale, e o cliente pode apenas
+// falhar mais cedo. Valores inválidos ou acima do limite retornam 422.
+func withClientTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, err := parseClientTimeout(r.URL.Query().Get("timeout"), clientTimeoutLimit())
+		if err != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+			return
+		}
+		if timeout == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeoutCause(r.Context(), timeout, errClientTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// breakerOutcome traduz o resultado de uma chamada à WeatherAPI para o circuit breaker e a
+// taxa de erros. Uma falha causada pelo ?timeout= do cliente vira errClientTimeout: o prazo
+// foi escolha dele e não diz nada sobre o provedor, e contá-la permitiria a qualquer cliente
+// abrir o circuito compartilhado com prazos curtos.
+func breakerOutcome(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), errClientTimeout) {
+		return errClientTimeout
+	}
+	return err
+}
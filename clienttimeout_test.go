@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClientTimeout(t *testing.T) {
+	limit := 12 * time.Second
+	testCases := []struct {
+		raw      string
+		expected time.Duration
+		valid    bool
+	}{
+		{"", 0, true},
+		{"3s", 3 * time.Second, true},
+		{"500ms", 500 * time.Millisecond, true},
+		{"12s", 12 * time.Second, true},
+		{"13s", 0, false},
+		{"1h", 0, false},
+		{"0s", 0, false},
+		{"-1s", 0, false},
+		{"3", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			timeout, err := parseClientTimeout(tc.raw, limit)
+			if (err == nil) != tc.valid || timeout != tc.expected {
+				t.Errorf("got (%v, %v) want (%v, valid=%v)", timeout, err, tc.expected, tc.valid)
+			}
+		})
+	}
+}
+
+func TestClientTimeoutLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	totalRequestBudget = 5 * time.Second
+	if limit := clientTimeoutLimit(); limit != 5*time.Second {
+		t.Errorf("expected the request budget as the limit, got %s", limit)
+	}
+	totalRequestBudget = 0
+	if limit := clientTimeoutLimit(); limit != defaultTotalRequestBudget {
+		t.Errorf("expected the default budget when disabled, got %s", limit)
+	}
+}
+
+func TestRouter_ClientTimeoutShortensDeadline(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+	mockWeatherAPIDelay = 500 * time.Millisecond
+
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?timeout=100ms", nil))
+	elapsed := time.Since(start)
+
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Errorf("got status %d want %d (body %q)", rr.Code, http.StatusGatewayTimeout, rr.Body.String())
+	}
+	if elapsed >= 500*time.Millisecond {
+		t.Errorf("expected the request to fail after the client timeout, took %s", elapsed)
+	}
+}
+
+func TestRouter_ClientTimeoutWithinDeadline(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?timeout=3s", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("got status %d want %d (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+}
+
+func TestRouter_ClientTimeoutRejected(t *testing.T) {
+	setup()
+	defer teardown()
+
+	totalRequestBudget = 5 * time.Second
+	testCases := map[string]string{
+		"1m":   "timeout must not exceed 5s",
+		"6s":   "timeout must not exceed 5s",
+		"fast": errorInvalidTimeout,
+		"0s":   errorInvalidTimeout,
+	}
+
+	for raw, message := range testCases {
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?timeout="+raw, nil))
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("timeout=%s: got status %d want %d", raw, rr.Code, http.StatusUnprocessableEntity)
+		}
+		if body := strings.TrimSpace(rr.Body.String()); body != message {
+			t.Errorf("timeout=%s: got body %q want %q", raw, body, message)
+		}
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls for rejected timeouts, got %d", calls)
+	}
+}
+
+func TestRouter_ClientTimeoutDoesNotTripBreaker(t *testing.T) {
+	setup()
+	defer teardown()
+
+	weatherBreaker = newCircuitBreaker("weatherapi", 2, time.Minute)
+	weatherErrorRate = newErrorRateTracker(0.5, time.Minute, 1)
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+	mockWeatherAPIDelay = 200 * time.Millisecond
+	router := newRouter()
+
+	// Prazos curtos escolhidos pelo cliente não podem abrir o circuito compartilhado
+	for i := 0; i < 5; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?timeout=20ms", nil))
+		if rr.Code != http.StatusGatewayTimeout {
+			t.Fatalf("request %d: got status %d want %d (body %q)", i+1, rr.Code, http.StatusGatewayTimeout, rr.Body.String())
+		}
+	}
+	if weatherBreaker.state != circuitClosed {
+		t.Errorf("expected the breaker to stay closed, got %s", weatherBreaker.state)
+	}
+	if weatherErrorRate.degraded() {
+		t.Error("expected client timeouts not to count towards degraded mode")
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("other clients: got status %d want %d (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+}
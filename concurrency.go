@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const maxConcurrentUpstreamEnv = "MAX_CONCURRENT_UPSTREAM"
+
+// errUpstreamBusy indica que não houve vaga para uma nova chamada externa dentro do prazo da requisição
+var errUpstreamBusy = errors.New(errorUpstreamBusy)
+
+// upstreamSlots limita as chamadas simultâneas a ViaCEP, BrasilAPI e WeatherAPI; nil desativa o limite
+var upstreamSlots *upstreamLimiter
+
+// upstreamLimiter é um semáforo compartilhado por todas as requisições do processo
+type upstreamLimiter struct {
+	slots chan struct{}
+}
+
+// newUpstreamLimiter cria um semáforo com a quantidade de vagas informada
+func newUpstreamLimiter(size int) *upstreamLimiter {
+	return &upstreamLimiter{slots: make(chan struct{}, size)}
+}
+
+// acquire aguarda uma vaga até o fim do prazo do contexto. A função retornada libera a vaga.
+func (l *upstreamLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %v", errUpstreamBusy, ctx.Err())
+	}
+}
+
+// doUpstreamRequest executa uma chamada externa ocupando uma vaga do semáforo. A vaga é
+// liberada quando os cabeçalhos da resposta chegam: os corpos são pequenos, e liberar antes
+// da leitura evita que uma consulta encadeada (ex: coordenadas após o ViaCEP) espere por si mesma.
+func doUpstreamRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	release, err := upstreamSlots.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return client.Do(req)
+}
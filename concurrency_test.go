@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpstreamLimiter_Acquire(t *testing.T) {
+	limiter := newUpstreamLimiter(1)
+	release, err := limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error acquiring a free slot: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.acquire(ctx); !errors.Is(err, errUpstreamBusy) {
+		t.Errorf("expected errUpstreamBusy with the semaphore full, got %v", err)
+	}
+
+	release()
+	release, err = limiter.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected the released slot to be available, got %v", err)
+	}
+	release()
+
+	var disabled *upstreamLimiter
+	if _, err := disabled.acquire(ctx); err != nil {
+		t.Errorf("expected nil limiter to never block, got %v", err)
+	}
+}
+
+func TestWeatherHandler_UpstreamConcurrencyLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	upstreamSlots = newUpstreamLimiter(1)
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+	mockViaCEPDelay = 300 * time.Millisecond
+
+	// A primeira requisição ocupa a única vaga enquanto o ViaCEP demora a responder
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		weatherHandler(first, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	}()
+	deadline := time.Now().Add(time.Second)
+	for mockViaCEPCalls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A requisição excedente não consegue vaga dentro do seu prazo
+	totalRequestBudget = 50 * time.Millisecond
+	overflow := httptest.NewRecorder()
+	weatherHandler(overflow, httptest.NewRequest(http.MethodGet, "/weather/20040002", nil))
+
+	if overflow.Code != http.StatusServiceUnavailable {
+		t.Errorf("overflow request: got status %v want %v", overflow.Code, http.StatusServiceUnavailable)
+	}
+	if body := strings.TrimSpace(overflow.Body.String()); body != errorUpstreamBusy {
+		t.Errorf("overflow request: got body %q want %q", body, errorUpstreamBusy)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected the overflow request to never reach ViaCEP, got %d calls", calls)
+	}
+
+	<-done
+	if first.Code != http.StatusOK {
+		t.Errorf("first request: got status %v want %v", first.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+// WeatherAPICondition Struct para a condição do tempo retornada pela WeatherAPI (ex: "Sunny")
+type WeatherAPICondition struct {
+	Text string `json:"text"`
+	Icon string `json:"icon"` // URL sem esquema (ex: "//cdn.weatherapi.com/weather/64x64/day/113.png")
+}
+
+// ConditionResponse Struct para o objeto condition da nossa API (?fields=condition)
+type ConditionResponse struct {
+	Text string `json:"text" xml:"text"`
+	Icon string `json:"icon,omitempty" xml:"icon,omitempty"`
+}
+
+// newConditionResponse converte a condição da WeatherAPI para a nossa resposta; sem texto
+// nem ícone, o objeto é omitido
+func newConditionResponse(condition *WeatherAPICondition) *ConditionResponse {
+	if condition == nil || (condition.Text == "" && condition.Icon == "") {
+		return nil
+	}
+	return &ConditionResponse{
+		Text: strings.TrimSpace(condition.Text),
+		Icon: normalizeIconURL(condition.Icon),
+	}
+}
+
+// normalizeIconURL garante que o ícone seja servido por HTTPS, evitando conteúdo misto em
+// páginas seguras. A WeatherAPI envia URLs relativas ao esquema ("//cdn...") ou com http://.
+func normalizeIconURL(icon string) string {
+	icon = strings.TrimSpace(icon)
+	switch {
+	case icon == "":
+		return ""
+	case strings.HasPrefix(icon, "//"):
+		return "https:" + icon
+	case strings.HasPrefix(strings.ToLower(icon), "http://"):
+		return "https://" + icon[len("http://"):]
+	}
+	return icon
+}
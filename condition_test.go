@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeIconURL(t *testing.T) {
+	testCases := map[string]string{
+		"//cdn.weatherapi.com/weather/64x64/day/113.png":       "https://cdn.weatherapi.com/weather/64x64/day/113.png",
+		"http://cdn.weatherapi.com/weather/64x64/day/113.png":  "https://cdn.weatherapi.com/weather/64x64/day/113.png",
+		"HTTP://cdn.weatherapi.com/weather/64x64/day/113.png":  "https://cdn.weatherapi.com/weather/64x64/day/113.png",
+		"https://cdn.weatherapi.com/weather/64x64/day/113.png": "https://cdn.weatherapi.com/weather/64x64/day/113.png",
+		" //cdn.weatherapi.com/a.png ":                         "https://cdn.weatherapi.com/a.png",
+		"":                                                     "",
+	}
+	for input, expected := range testCases {
+		if got := normalizeIconURL(input); got != expected {
+			t.Errorf("normalizeIconURL(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestWeatherAPIResponse_ParsesCondition(t *testing.T) {
+	payload := `{"current": {"temp_c": 30.0, "condition": {"text": "Partly cloudy", "icon": "//cdn.weatherapi.com/weather/64x64/day/116.png", "code": 1003}}}`
+
+	var weatherResp WeatherAPIResponse
+	if err := json.Unmarshal([]byte(payload), &weatherResp); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	condition := weatherResp.Current.Condition
+	if condition == nil || condition.Text != "Partly cloudy" || condition.Icon != "//cdn.weatherapi.com/weather/64x64/day/116.png" {
+		t.Fatalf("unexpected condition: %+v", condition)
+	}
+
+	expected := ConditionResponse{Text: "Partly cloudy", Icon: "https://cdn.weatherapi.com/weather/64x64/day/116.png"}
+	if response := newConditionResponse(condition); response == nil || *response != expected {
+		t.Errorf("got %+v want %+v", response, expected)
+	}
+	if response := newConditionResponse(&WeatherAPICondition{}); response != nil {
+		t.Errorf("expected empty condition to be omitted, got %+v", response)
+	}
+}
+
+func TestWeatherHandler_Condition(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+
+	testCases := []struct {
+		name     string
+		query    string
+		weather  string
+		expected *ConditionResponse
+	}{
+		{
+			name:     "requested",
+			query:    "?fields=condition",
+			weather:  `{"current": {"temp_c": 25.0, "condition": {"text": "Sunny", "icon": "//cdn.weatherapi.com/weather/64x64/day/113.png"}}}`,
+			expected: &ConditionResponse{Text: "Sunny", Icon: "https://cdn.weatherapi.com/weather/64x64/day/113.png"},
+		},
+		{
+			name:    "not requested",
+			query:   "",
+			weather: `{"current": {"temp_c": 25.0, "condition": {"text": "Sunny", "icon": "//cdn.weatherapi.com/weather/64x64/day/113.png"}}}`,
+		},
+		{
+			name:    "missing upstream",
+			query:   "?fields=condition",
+			weather: `{"current": {"temp_c": 25.0}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWeatherAPIResponse = tc.weather
+			weatherCache.clear()
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+
+			var response WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			switch {
+			case tc.expected == nil && response.Condition != nil:
+				t.Errorf("expected no condition, got %+v", response.Condition)
+			case tc.expected != nil && (response.Condition == nil || *response.Condition != *tc.expected):
+				t.Errorf("got condition %+v want %+v", response.Condition, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envString lê uma variável de ambiente textual, usando o valor padrão quando ausente
+func envString(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// envInt lê uma variável de ambiente inteira, usando o valor padrão quando ausente ou inválida
+func envInt(name string, defaultValue int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		slog.Warn("Invalid integer in environment, using default", "env", name, "value", raw, "default", defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// envDuration lê uma variável de ambiente no formato de duração do Go (ex: "5s"),
+// usando o valor padrão quando ausente ou inválida
+func envDuration(name string, defaultValue time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		slog.Warn("Invalid duration in environment, using default", "env", name, "value", raw, "default", defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// envBool lê uma variável de ambiente booleana (ex: "true", "1"), usando o valor padrão quando ausente ou inválida
+func envBool(name string, defaultValue bool) bool {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		slog.Warn("Invalid boolean in environment, using default", "env", name, "value", raw, "default", defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// envFloat lê uma variável de ambiente numérica (ex: "0.5"), usando o valor padrão quando ausente ou inválida
+func envFloat(name string, defaultValue float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		slog.Warn("Invalid number in environment, using default", "env", name, "value", raw, "default", defaultValue)
+		return defaultValue
+	}
+	return value
+}
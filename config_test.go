@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnvDuration(t *testing.T) {
+	const name = "TEST_ENV_DURATION"
+	defaultValue := 10 * time.Second
+
+	testCases := []struct {
+		raw      string
+		expected time.Duration
+	}{
+		{"", defaultValue},                // Ausente
+		{"5s", 5 * time.Second},           // Válido
+		{"1m30s", 90 * time.Second},       // Válido composto
+		{"250ms", 250 * time.Millisecond}, // Válido em milissegundos
+		{"abc", defaultValue},             // Inválido
+		{"10", defaultValue},              // Sem unidade
+		{"-5s", defaultValue},             // Negativo
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Setenv(name, tc.raw)
+			if value := envDuration(name, defaultValue); value != tc.expected {
+				t.Errorf("envDuration(%q) = %s, want %s", tc.raw, value, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLoadUpstreamURLs(t *testing.T) {
+	// Preserva as URLs do servidor mock usadas pelos demais testes
+	previousViaCEP, previousWeatherAPI, previousOpenMeteo := viaCEPURL, weatherAPIURL, openMeteoURL
+	t.Cleanup(func() { viaCEPURL, weatherAPIURL, openMeteoURL = previousViaCEP, previousWeatherAPI, previousOpenMeteo })
+
+	t.Run("env overrides defaults", func(t *testing.T) {
+		t.Setenv(viaCEPURLEnv, "http://viacep.internal:8081/")
+		t.Setenv(weatherAPIURLEnv, "http://weather-gateway.internal")
+		t.Setenv(openMeteoURLEnv, "http://open-meteo.internal/")
+
+		loadUpstreamURLs()
+
+		if viaCEPURL != "http://viacep.internal:8081" {
+			t.Errorf("unexpected ViaCEP URL: %s", viaCEPURL)
+		}
+		if weatherAPIURL != "http://weather-gateway.internal" {
+			t.Errorf("unexpected WeatherAPI URL: %s", weatherAPIURL)
+		}
+		if openMeteoURL != "http://open-meteo.internal" {
+			t.Errorf("unexpected Open-Meteo URL: %s", openMeteoURL)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(viaCEPURLEnv, "")
+		t.Setenv(weatherAPIURLEnv, "")
+		t.Setenv(openMeteoURLEnv, "")
+
+		loadUpstreamURLs()
+
+		if viaCEPURL != defaultViaCEPURL || weatherAPIURL != defaultWeatherAPIURL || openMeteoURL != defaultOpenMeteoURL {
+			t.Errorf("expected default URLs, got %s, %s and %s", viaCEPURL, weatherAPIURL, openMeteoURL)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+// TemperatureConverter converte uma temperatura em Celsius para as demais escalas, com o
+// número de casas decimais informado. Os handlers dependem apenas desta interface, de modo
+// que novas escalas ou fórmulas alternativas não exigem mudanças no fluxo das requisições.
+type TemperatureConverter interface {
+	Fahrenheit(celsius float64, precision uint) float64
+	Kelvin(celsius float64, precision uint) float64
+	Rankine(celsius float64, precision uint) float64
+	Reaumur(celsius float64, precision uint) float64
+	Newton(celsius float64, precision uint) float64
+}
+
+// temperatureConverter é o conversor usado nas respostas; substituível nos testes
+var temperatureConverter TemperatureConverter = standardConverter{}
+
+// kelvinOffset é o deslocamento entre Celsius e Kelvin: 273, conforme especificado, embora
+// 273.15 seja mais preciso. Também define o zero absoluto aceito em isPhysicalTemperature.
+const kelvinOffset = 273
+
+// standardConverter aplica as fórmulas documentadas no README
+type standardConverter struct{}
+
+// Fahrenheit converte Celsius para Fahrenheit
+func (standardConverter) Fahrenheit(celsius float64, precision uint) float64 {
+	// F = C * 1.8 + 32
+	fahrenheit := celsius*1.8 + 32
+	return roundFloat(fahrenheit, precision)
+}
+
+// Kelvin converte Celsius para Kelvin
+func (standardConverter) Kelvin(celsius float64, precision uint) float64 {
+	// K = C + 273
+	kelvin := celsius + kelvinOffset
+	return roundFloat(kelvin, precision)
+}
+
+// Rankine converte Celsius para Rankine
+func (standardConverter) Rankine(celsius float64, precision uint) float64 {
+	// R = (C + 273.15) * 9/5
+	rankine := (celsius + 273.15) * 9 / 5
+	return roundFloat(rankine, precision)
+}
+
+// Reaumur converte Celsius para Réaumur
+func (standardConverter) Reaumur(celsius float64, precision uint) float64 {
+	// Ré = C * 0.8
+	reaumur := celsius * 0.8
+	return roundFloat(reaumur, precision)
+}
+
+// Newton converte Celsius para Newton
+func (standardConverter) Newton(celsius float64, precision uint) float64 {
+	// N = C * 33/100
+	newton := celsius * 33 / 100
+	return roundFloat(newton, precision)
+}
+
+// celsiusToFahrenheit converte Celsius para Fahrenheit com 1 casa decimal
+func celsiusToFahrenheit(celsius float64) float64 {
+	return standardConverter{}.Fahrenheit(celsius, 1)
+}
+
+// celsiusToKelvin converte Celsius para Kelvin com 1 casa decimal
+func celsiusToKelvin(celsius float64) float64 {
+	return standardConverter{}.Kelvin(celsius, 1)
+}
+
+// celsiusToRankine converte Celsius para Rankine com 1 casa decimal
+func celsiusToRankine(celsius float64) float64 {
+	return standardConverter{}.Rankine(celsius, 1)
+}
+
+// celsiusToReaumur converte Celsius para Réaumur com 1 casa decimal
+func celsiusToReaumur(celsius float64) float64 {
+	return standardConverter{}.Reaumur(celsius, 1)
+}
+
+// celsiusToNewton converte Celsius para Newton com 1 casa decimal
+func celsiusToNewton(celsius float64) float64 {
+	return standardConverter{}.Newton(celsius, 1)
+}
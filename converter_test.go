@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mockConverter registra as chamadas e retorna valores fixos, distintos das fórmulas reais
+type mockConverter struct {
+	calls []string
+}
+
+func (m *mockConverter) Fahrenheit(celsius float64, precision uint) float64 {
+	m.calls = append(m.calls, "fahrenheit")
+	return 1
+}
+
+func (m *mockConverter) Kelvin(celsius float64, precision uint) float64 {
+	m.calls = append(m.calls, "kelvin")
+	return 2
+}
+
+func (m *mockConverter) Rankine(celsius float64, precision uint) float64 {
+	m.calls = append(m.calls, "rankine")
+	return 3
+}
+
+func (m *mockConverter) Reaumur(celsius float64, precision uint) float64 {
+	m.calls = append(m.calls, "reaumur")
+	return 4
+}
+
+func (m *mockConverter) Newton(celsius float64, precision uint) float64 {
+	m.calls = append(m.calls, "newton")
+	return 5
+}
+
+func TestStandardConverter(t *testing.T) {
+	var converter TemperatureConverter = standardConverter{}
+	testCases := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"fahrenheit", converter.Fahrenheit(25.5, 1), 77.9},
+		{"fahrenheit precision", converter.Fahrenheit(25.55, 2), 77.99},
+		{"kelvin", converter.Kelvin(25.5, 1), 298.5},
+		{"kelvin whole", converter.Kelvin(25.5, 0), 299},
+		{"rankine", converter.Rankine(0, 2), 491.67},
+		{"reaumur", converter.Reaumur(100, 1), 80},
+		{"newton", converter.Newton(100, 1), 33},
+		{"newton precision", converter.Newton(25.5, 3), 8.415},
+	}
+
+	for _, tc := range testCases {
+		if tc.got != tc.expected {
+			t.Errorf("%s: got %v want %v", tc.name, tc.got, tc.expected)
+		}
+	}
+}
+
+func TestCelsiusToReaumurAndNewton(t *testing.T) {
+	testCases := []struct {
+		celsius float64
+		reaumur float64
+		newton  float64
+	}{
+		{0, 0, 0},
+		{100, 80, 33},            // Ponto de ebulição da água
+		{-40, -32, -13.2},        // -40°C = -32°Ré = -13.2°N
+		{25.5, 20.4, 8.4},        // 8.415°N
+		{37, 29.6, 12.2},         // 12.21°N
+		{-273.15, -218.5, -90.1}, // Zero absoluto: -218.52°Ré, -90.1395°N
+	}
+
+	for _, tc := range testCases {
+		if reaumur := celsiusToReaumur(tc.celsius); reaumur != tc.reaumur {
+			t.Errorf("celsiusToReaumur(%v) = %v, want %v", tc.celsius, reaumur, tc.reaumur)
+		}
+		if newton := celsiusToNewton(tc.celsius); newton != tc.newton {
+			t.Errorf("celsiusToNewton(%v) = %v, want %v", tc.celsius, newton, tc.newton)
+		}
+	}
+}
+
+func TestWeatherHandler_UsesTemperatureConverter(t *testing.T) {
+	setup()
+	defer teardown()
+
+	converter := &mockConverter{}
+	temperatureConverter = converter
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?scales=rankine", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.TempC != 25.5 || response.TempF != 1 || response.TempK != 2 || response.TempR == nil || *response.TempR != 3 {
+		t.Errorf("expected the converter values in the response, got %+v", response)
+	}
+
+	calls := map[string]int{}
+	for _, call := range converter.calls {
+		calls[call]++
+	}
+	for _, scale := range []string{"fahrenheit", "kelvin", "rankine"} {
+		if calls[scale] != 1 {
+			t.Errorf("expected one %s conversion, got %d", scale, calls[scale])
+		}
+	}
+}
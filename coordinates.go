@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const brasilAPIURLFormat = "%s/api/cep/v2/%s"
+
+// errNoWeatherStation indica que a WeatherAPI não encontrou dados para as coordenadas do CEP
+// (ex: um ponto no oceano), o que é diferente de um CEP inexistente
+var errNoWeatherStation = errors.New(errorNoWeatherStation)
+
+// brasilAPIURL é a URL base da BrasilAPI, usada para obter as coordenadas do CEP
+var brasilAPIURL = "https://brasilapi.com.br"
+
+// BrasilAPICEPResponse Struct para a resposta da BrasilAPI (v2), que inclui coordenadas
+type BrasilAPICEPResponse struct {
+	Location struct {
+		Coordinates struct {
+			Latitude  string `json:"latitude"` // A BrasilAPI retorna as coordenadas como texto
+			Longitude string `json:"longitude"`
+		} `json:"coordinates"`
+	} `json:"location"`
+}
+
+// coordinates representa uma posição geográfica em graus decimais
+type coordinates struct {
+	Lat float64
+	Lon float64
+}
+
+// String formata as coordenadas no formato "lat,lon" aceito pela WeatherAPI
+func (c coordinates) String() string {
+	return strconv.FormatFloat(c.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(c.Lon, 'f', -1, 64)
+}
+
+// getCoordinatesFromCEP busca as coordenadas de um CEP na BrasilAPI.
+// Retorna nil (sem erro) quando o CEP não possui coordenadas cadastradas.
+func getCoordinatesFromCEP(ctx context.Context, client *http.Client, cep string) (*coordinates, error) {
+	if err := consumeAttempt(ctx); err != nil {
+		return nil, err
+	}
+
+	coordsURL := fmt.Sprintf(brasilAPIURLFormat, brasilAPIURL, cep)
+	req, err := newUpstreamRequest(ctx, coordsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create BrasilAPI request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := doUpstreamRequest(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute BrasilAPI request: %w", err)
+	}
+	defer resp.Body.Close()
+	logUpstreamResponse(ctx, "brasilapi", resp.StatusCode, start)
+	logUpstreamBody(ctx, "brasilapi", resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("BrasilAPI request failed with status: %s", resp.Status)
+	}
+
+	var brasilAPIResp BrasilAPICEPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&brasilAPIResp); err != nil {
+		return nil, fmt.Errorf("failed to decode BrasilAPI response: %w", err)
+	}
+
+	// Nem todo CEP tem coordenadas; nesse caso a BrasilAPI retorna os campos vazios
+	raw := brasilAPIResp.Location.Coordinates
+	if raw.Latitude == "" || raw.Longitude == "" {
+		return nil, nil
+	}
+	lat, errLat := strconv.ParseFloat(raw.Latitude, 64)
+	lon, errLon := strconv.ParseFloat(raw.Longitude, 64)
+	if errLat != nil || errLon != nil {
+		return nil, fmt.Errorf("invalid BrasilAPI coordinates %q,%q", raw.Latitude, raw.Longitude)
+	}
+
+	return &coordinates{Lat: lat, Lon: lon}, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherHandler_QueriesByCoordinates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"cep": "01001000", "city": "São Paulo", "location": {"type": "Point", "coordinates": {"longitude": "-46.6333", "latitude": "-23.5505"}}}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+	expectWeatherAPICity = "-23.5505,-46.6333" // O mock rejeita qualquer outra consulta
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockBrasilAPICalls.Load(); calls != 1 {
+		t.Errorf("expected 1 BrasilAPI call, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_FallsBackToCityName(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		response   string
+	}{
+		{"BrasilAPI unavailable", http.StatusInternalServerError, `{"message": "error"}`},
+		{"CEP not found in BrasilAPI", http.StatusNotFound, `{"message": "not found"}`},
+		{"CEP without coordinates", http.StatusOK, `{"cep": "01001000", "location": {"type": "Point", "coordinates": {}}}`},
+		{"malformed coordinates", http.StatusOK, `{"location": {"coordinates": {"longitude": "abc", "latitude": "-23.5"}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+			mockBrasilAPIStatusCode = tc.statusCode
+			mockBrasilAPIResponse = tc.response
+			mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+			expectWeatherAPICity = "São Paulo,SP,Brazil" // Sem coordenadas, consulta por cidade e UF
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_NoWeatherStationNearCoordinates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "Fernando de Noronha", "uf": "PE"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"cep": "53990000", "location": {"type": "Point", "coordinates": {"longitude": "-30.0", "latitude": "-10.0"}}}`
+	mockWeatherAPIStatusCode = http.StatusBadRequest
+	mockWeatherAPIResponse = `{"error": {"code": 1006, "message": "No matching location found."}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/53990000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorNoWeatherStation {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorNoWeatherStation)
+	}
+}
+
+func TestWeatherHandler_CityOnlyRoutesSkipCoordinates(t *testing.T) {
+	for _, path := range []string{"/weather/01001000?only_city=true", "/resolve/01001000"} {
+		t.Run(path, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+			mockBrasilAPIStatusCode = http.StatusOK
+			mockBrasilAPIResponse = `{"cep": "01001000", "location": {"type": "Point", "coordinates": {"longitude": "-46.6333", "latitude": "-23.5505"}}}`
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if calls := mockBrasilAPICalls.Load(); calls != 0 {
+				t.Errorf("expected no BrasilAPI calls when only the city is needed, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_CompletesCachedCEPWithCoordinates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"cep": "01001000", "location": {"type": "Point", "coordinates": {"longitude": "-46.6333", "latitude": "-23.5505"}}}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+	expectWeatherAPICity = "-23.5505,-46.6333"
+	cepCacheTTL = time.Hour
+	router := newRouter()
+
+	// A primeira consulta guarda o CEP sem coordenadas; as de clima as completam uma única vez
+	for _, path := range []string{"/weather/01001000?only_city=true", "/weather/01001000?extended=true", "/weather/01001000"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: got status %v want %v (body %s)", path, rr.Code, http.StatusOK, rr.Body.String())
+		}
+		if path == "/weather/01001000?extended=true" && !strings.Contains(rr.Body.String(), `"sources":["viacep-cache","brasilapi","weatherapi"]`) {
+			t.Errorf("unexpected sources: %s", rr.Body.String())
+		}
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected 1 ViaCEP call, got %d", calls)
+	}
+	if calls := mockBrasilAPICalls.Load(); calls != 1 {
+		t.Errorf("expected 1 BrasilAPI call, got %d", calls)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	degradedErrorRateEnv   = "DEGRADED_ERROR_RATE"
+	degradedWindowEnv      = "DEGRADED_WINDOW"
+	degradedMinRequestsEnv = "DEGRADED_MIN_REQUESTS"
+
+	defaultDegradedWindow      = time.Minute
+	defaultDegradedMinRequests = 10
+
+	// errorRateBuckets é a quantidade de fatias da janela deslizante
+	errorRateBuckets = 10
+
+	// degradedWarning é o cabeçalho Warning (RFC 7234, código 110 "Response is Stale")
+	// enviado nas respostas servidas em modo degradado
+	degradedWarning = `110 - "degraded mode: serving cached weather data"`
+)
+
+// weatherErrorRate acompanha a taxa de erros da WeatherAPI; nil desativa o modo degradado
+var weatherErrorRate *errorRateTracker
+
+// rateBucket acumula os resultados de uma fatia da janela
+type rateBucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// errorRateTracker mede a taxa de falhas em uma janela deslizante e indica quando ela
+// passa do limite configurado. Diferente do circuit breaker, que reage a falhas
+// consecutivas, ele detecta incidentes parciais em que parte das chamadas ainda funciona.
+type errorRateTracker struct {
+	mu          sync.Mutex
+	threshold   float64 // Fração de falhas (0 a 1) a partir da qual o serviço entra em modo degradado
+	minRequests int     // Amostras mínimas na janela para que a taxa seja considerada
+	width       time.Duration
+	buckets     [errorRateBuckets]rateBucket
+	now         func() time.Time // Injetável para testes
+}
+
+// newErrorRateTracker cria um acompanhamento de taxa de erros para a janela informada
+func newErrorRateTracker(threshold float64, window time.Duration, minRequests int) *errorRateTracker {
+	return &errorRateTracker{
+		threshold:   threshold,
+		minRequests: minRequests,
+		width:       max(window/errorRateBuckets, time.Millisecond),
+		now:         time.Now,
+	}
+}
+
+// record registra o resultado de uma chamada; erros que não refletem a saúde do provedor
+// (os mesmos ignorados pelo circuit breaker) contam como sucesso. Chamadas interrompidas pelo
+// ?timeout= do cliente não entram na amostra, para que ele não altere a taxa em nenhum sentido.
+func (t *errorRateTracker) record(err error) {
+	if t == nil || errors.Is(err, errClientTimeout) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := t.bucket(t.now())
+	bucket.total++
+	if err != nil && isBreakerFailure(err) {
+		bucket.failures++
+	}
+}
+
+// degraded informa se a taxa de falhas na janela atingiu o limite
+func (t *errorRateTracker) degraded() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	total, failures := 0, 0
+	for _, bucket := range t.buckets {
+		if now.Sub(bucket.start) < t.width*errorRateBuckets {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+	if total == 0 || total < t.minRequests {
+		return false
+	}
+	return float64(failures)/float64(total) >= t.threshold
+}
+
+// bucket retorna a fatia correspondente ao instante, zerando-a se pertencer a uma volta
+// anterior da janela; deve ser chamado com o lock adquirido
+func (t *errorRateTracker) bucket(now time.Time) *rateBucket {
+	start := now.Truncate(t.width)
+	bucket := &t.buckets[(start.UnixNano()/int64(t.width))%errorRateBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = rateBucket{start: start}
+	}
+	return bucket
+}
+
+// setDegradedHeader sinaliza ao cliente que a resposta foi servida em modo degradado
+func setDegradedHeader(w http.ResponseWriter, weather *weatherReading) {
+	if weather.Degraded {
+		w.Header().Set("Warning", degradedWarning)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestErrorRateTracker_Window(t *testing.T) {
+	now := time.Unix(0, 0)
+	tracker := newErrorRateTracker(0.5, time.Minute, 4)
+	tracker.now = func() time.Time { return now }
+	failure := errors.New("upstream failure")
+
+	// Abaixo do mínimo de amostras a taxa não é considerada
+	tracker.record(failure)
+	tracker.record(failure)
+	tracker.record(failure)
+	if tracker.degraded() {
+		t.Fatal("expected no degraded mode below the minimum number of requests")
+	}
+
+	tracker.record(nil)
+	if !tracker.degraded() {
+		t.Fatal("expected degraded mode with 3 failures out of 4 requests")
+	}
+
+	// "Não encontrado" não conta como falha do provedor
+	for range 4 {
+		tracker.record(errCannotFindZip)
+	}
+	if tracker.degraded() {
+		t.Fatal("expected not-found responses to count as successes")
+	}
+
+	// Amostras fora da janela são descartadas
+	now = now.Add(2 * time.Minute)
+	tracker.record(failure)
+	if tracker.degraded() {
+		t.Error("expected old samples to leave the window")
+	}
+
+	var disabled *errorRateTracker
+	disabled.record(failure)
+	if disabled.degraded() {
+		t.Error("expected nil tracker to never report degraded mode")
+	}
+}
+
+func TestWeatherHandler_DegradedModeServesCache(t *testing.T) {
+	setup()
+	defer teardown()
+
+	weatherErrorRate = newErrorRateTracker(0.5, time.Minute, 4)
+
+	doRequest := func(cep string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/"+cep, nil)
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, req)
+		return rr
+	}
+
+	// Uma leitura bem-sucedida popula o cache
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 18.5}}`
+	if rr := doRequest("01001000"); rr.Code != http.StatusOK || rr.Header().Get("Warning") != "" {
+		t.Fatalf("initial request: got status %v, Warning %q", rr.Code, rr.Header().Get("Warning"))
+	}
+
+	// A WeatherAPI passa a falhar para outras cidades, elevando a taxa de erros
+	mockViaCEPResponse = `{"localidade": "Rio de Janeiro"}`
+	mockWeatherAPIResponse = `Weather API Service Unavailable`
+	mockWeatherAPIStatusCode = http.StatusInternalServerError
+	for range 4 {
+		if rr := doRequest("20040002"); rr.Code != http.StatusInternalServerError {
+			t.Fatalf("failing request: got status %v want %v", rr.Code, http.StatusInternalServerError)
+		}
+	}
+
+	// Em modo degradado o cache é servido sem consultar a WeatherAPI
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 30.0}}`
+	mockWeatherAPIStatusCode = http.StatusOK
+	callsBefore := mockWeatherAPICalls.Load()
+
+	rr := doRequest("01001000")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("degraded request: got status %v want %v", rr.Code, http.StatusOK)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != callsBefore {
+		t.Errorf("expected no WeatherAPI call in degraded mode, got %d", calls-callsBefore)
+	}
+	if warning := rr.Header().Get("Warning"); warning != degradedWarning {
+		t.Errorf("got Warning header %q want %q", warning, degradedWarning)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if !response.Degraded || response.TempC != 18.5 {
+		t.Errorf("expected degraded cached reading, got %+v", response)
+	}
+
+	// Sem leitura em cache a WeatherAPI ainda é consultada
+	mockViaCEPResponse = `{"localidade": "Curitiba"}`
+	if rr := doRequest("80010000"); rr.Code != http.StatusOK || rr.Header().Get("Warning") != "" {
+		t.Errorf("uncached request: got status %v, Warning %q", rr.Code, rr.Header().Get("Warning"))
+	}
+}
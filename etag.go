@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// weakETag calcula um ETag fraco sobre o corpo da resposta. É fraco porque o mesmo conteúdo
+// pode chegar comprimido (gzip) ou não, sem que a representação semântica mude.
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches aplica a comparação fraca do If-None-Match (RFC 9110): aceita uma lista de
+// ETags separados por vírgula ou "*", ignorando o prefixo W/
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeWithETag executa write sobre um buffer para calcular o ETag do corpo. Se o cliente já
+// tem essa versão (If-None-Match), responde 304 sem corpo. Como leituras servidas do cache
+// da WeatherAPI geram o mesmo corpo, clientes repetidos recebem 304 enquanto o cache vale.
+func writeWithETag(w http.ResponseWriter, r *http.Request, write func(http.ResponseWriter)) {
+	bw := &bufferedResponseWriter{ResponseWriter: w}
+	write(bw)
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	if bw.status != http.StatusOK {
+		w.WriteHeader(bw.status)
+		w.Write(bw.buf.Bytes())
+		return
+	}
+
+	etag := weakETag(bw.buf.Bytes())
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		// Um 304 não tem corpo; os cabeçalhos que descrevem o corpo são removidos
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bw.buf.Bytes())
+}
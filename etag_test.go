@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `W/"abc"`
+	testCases := []struct {
+		header   string
+		expected bool
+	}{
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"xyz", W/"abc"`, true},
+		{`*`, true},
+		{`W/"xyz"`, false},
+		{`abc`, false},
+	}
+
+	for _, tc := range testCases {
+		if got := etagMatches(tc.header, etag); got != tc.expected {
+			t.Errorf("etagMatches(%q): got %t want %t", tc.header, got, tc.expected)
+		}
+	}
+}
+
+func TestWeatherHandler_ETag(t *testing.T) {
+	setup()
+	defer teardown()
+
+	weatherCacheTTL = time.Minute
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	doRequest := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, req)
+		return rr
+	}
+
+	// Primeira requisição: 200 com o ETag do corpo
+	first := doRequest("")
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request: got status %v want %v", first.Code, http.StatusOK)
+	}
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expected a weak ETag, got %q", etag)
+	}
+	if expected := weakETag(first.Body.Bytes()); etag != expected {
+		t.Errorf("ETag does not match the body: got %q want %q", etag, expected)
+	}
+
+	// Mesmo ETag: 304 sem corpo, servido do cache sem consultar a WeatherAPI
+	callsBefore := mockWeatherAPICalls.Load()
+	notModified := doRequest(etag)
+	if notModified.Code != http.StatusNotModified {
+		t.Fatalf("conditional request: got status %v want %v", notModified.Code, http.StatusNotModified)
+	}
+	if notModified.Body.Len() != 0 {
+		t.Errorf("expected empty body on 304, got %q", notModified.Body.String())
+	}
+	if got := notModified.Header().Get("ETag"); got != etag {
+		t.Errorf("expected ETag %q on 304, got %q", etag, got)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != callsBefore {
+		t.Errorf("expected the cached reading to be reused, got %d WeatherAPI calls", calls-callsBefore)
+	}
+
+	// ETag desatualizado: 200 com o corpo completo
+	stale := doRequest(`W/"outdated"`)
+	if stale.Code != http.StatusOK || stale.Body.Len() == 0 {
+		t.Errorf("outdated ETag: got status %v with %d bytes", stale.Code, stale.Body.Len())
+	}
+
+	// Leitura nova gera outro ETag
+	weatherCache.clear()
+	mockWeatherAPIResponse = `{"current": {"temp_c": 26.0}}`
+	changed := doRequest(etag)
+	if changed.Code != http.StatusOK {
+		t.Errorf("changed reading: got status %v want %v", changed.Code, http.StatusOK)
+	}
+	if got := changed.Header().Get("ETag"); got == etag || got == "" {
+		t.Errorf("expected a new ETag for a new reading, got %q", got)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	weatherAPIForecastURLFormat = "%s/v1/forecast.json?key=%s&q=%s&days=%d&aqi=no&alerts=no"
+
+	forecastMaxDaysEnv     = "FORECAST_MAX_DAYS"
+	defaultForecastDays    = 3
+	defaultForecastMaxDays = 3  // Limite do plano gratuito da WeatherAPI
+	maxForecastDays        = 10 // Limite da nossa API, independente do plano
+)
+
+// forecastMaxDays é o máximo de dias de previsão permitido pelo plano da conta na WeatherAPI
+var forecastMaxDays = defaultForecastMaxDays
+
+// errInvalidForecastDays indica um valor de ?days= que não é um inteiro positivo
+var errInvalidForecastDays = errors.New(errorInvalidForecastDays)
+
+// parseForecastDays valida o parâmetro ?days= da previsão. Pedidos acima do limite do plano
+// retornam erro em vez de deixar a WeatherAPI truncar a resposta silenciosamente.
+func parseForecastDays(raw string) (int, error) {
+	if raw == "" {
+		return min(defaultForecastDays, forecastMaxDays), nil
+	}
+
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 1 {
+		return 0, errInvalidForecastDays
+	}
+	if days > forecastMaxDays {
+		return 0, fmt.Errorf("days must not exceed %d for the configured WeatherAPI plan", forecastMaxDays)
+	}
+	return days, nil
+}
+
+// WeatherAPIForecastResponse Struct para a resposta do endpoint forecast.json da WeatherAPI
+type WeatherAPIForecastResponse struct {
+	WeatherAPIResponse
+	Forecast struct {
+		ForecastDay []WeatherAPIForecastDay `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+// WeatherAPIForecastDay Struct para um dia da previsão, com datas no fuso da localização
+type WeatherAPIForecastDay struct {
+	Date string `json:"date"` // yyyy-MM-dd
+	Day  struct {
+		MaxTempC float64 `json:"maxtemp_c"`
+		MinTempC float64 `json:"mintemp_c"`
+	} `json:"day"`
+	Hour []WeatherAPIForecastHour `json:"hour"`
+}
+
+// WeatherAPIForecastHour Struct para uma hora da previsão
+type WeatherAPIForecastHour struct {
+	TimeEpoch int64   `json:"time_epoch"`
+	Time      string  `json:"time"` // yyyy-MM-dd HH:mm, no fuso da localização
+	TempC     float64 `json:"temp_c"`
+}
+
+// DailyForecast Struct para as temperaturas mínima e máxima previstas para um dia
+type DailyForecast struct {
+	Date     string  `json:"date" xml:"date"` // yyyy-MM-dd, no fuso da localização
+	MinTempC float64 `json:"min_temp_C" xml:"min_temp_C"`
+	MaxTempC float64 `json:"max_temp_C" xml:"max_temp_C"`
+	MinTempF float64 `json:"min_temp_F" xml:"min_temp_F"`
+	MaxTempF float64 `json:"max_temp_F" xml:"max_temp_F"`
+	MinTempK float64 `json:"min_temp_K" xml:"min_temp_K"`
+	MaxTempK float64 `json:"max_temp_K" xml:"max_temp_K"`
+}
+
+// DailyForecasts lista de dias da previsão. Em JSON é um array simples; em XML é
+// envolvida por <forecast>, já que um documento XML precisa de um único elemento raiz.
+type DailyForecasts []DailyForecast
+
+// MarshalXML serializa a previsão como <forecast><day>...</day></forecast>
+func (d DailyForecasts) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "forecast"
+	return e.EncodeElement(struct {
+		Days []DailyForecast `xml:"day"`
+	}{d}, start)
+}
+
+// forecastHandler atende GET /weather/{cep}/forecast?days=N, retornando as temperaturas
+// mínima e máxima de cada dia em Celsius, Fahrenheit e Kelvin
+func forecastHandler(w http.ResponseWriter, r *http.Request) {
+	cep := r.PathValue("cep")
+	if !isValidCEP(cep) {
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidZipcode) // 422
+		return
+	}
+
+	days, err := parseForecastDays(r.URL.Query().Get("days"))
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+		return
+	}
+
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	location, _, err := lookupCEP(ctx, defaultClients, cep, true)
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting city from CEP", "cep", cep, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	forecast, err := getForecastForLocation(ctx, defaultClients, location, days)
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting forecast for city", "cep", cep, "city", location.City, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, newDailyForecasts(forecast.Forecast.ForecastDay))
+}
+
+// newDailyForecasts converte os dias da previsão da WeatherAPI para a nossa resposta
+func newDailyForecasts(days []WeatherAPIForecastDay) DailyForecasts {
+	forecasts := make(DailyForecasts, 0, len(days))
+	for _, day := range days {
+		minC, maxC := day.Day.MinTempC, day.Day.MaxTempC
+		forecasts = append(forecasts, DailyForecast{
+			Date:     day.Date,
+			MinTempC: minC,
+			MaxTempC: maxC,
+			MinTempF: temperatureConverter.Fahrenheit(minC, 1),
+			MaxTempF: temperatureConverter.Fahrenheit(maxC, 1),
+			MinTempK: temperatureConverter.Kelvin(minC, 1),
+			MaxTempK: temperatureConverter.Kelvin(maxC, 1),
+		})
+	}
+	return forecasts
+}
+
+// getForecastForLocation busca a previsão de days dias para a localização resolvida a partir
+// do CEP, passando pelo mesmo circuit breaker das condições atuais
+func getForecastForLocation(ctx context.Context, clients upstreamClients, location cepLocation, days int) (*WeatherAPIForecastResponse, error) {
+	query := weatherQuery(location)
+
+	if !weatherBreaker.allow() {
+		return nil, errCircuitOpen
+	}
+	forecast, err := fetchForecast(ctx, clients.WeatherAPI, query, days)
+	weatherBreaker.record(breakerOutcome(ctx, err))
+
+	if location.Coordinates != nil && errors.Is(err, errCannotFindZip) {
+		return nil, errNoWeatherStation
+	}
+	return forecast, err
+}
+
+// fetchForecast consulta o endpoint forecast.json da WeatherAPI
+func fetchForecast(ctx context.Context, client *http.Client, query string, days int) (*WeatherAPIForecastResponse, error) {
+	requestURL := fmt.Sprintf(weatherAPIForecastURLFormat, weatherAPIURL, weatherAPIKey, url.QueryEscape(query), days)
+
+	var forecast WeatherAPIForecastResponse
+	if err := callWeatherAPI(ctx, client, requestURL, query, &forecast); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Forecast resolved", "query", query, "days", len(forecast.Forecast.ForecastDay))
+	return &forecast, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseForecastDays(t *testing.T) {
+	setup()
+	defer teardown()
+
+	forecastMaxDays = 3
+
+	testCases := []struct {
+		raw           string
+		expectedDays  int
+		expectedError string
+	}{
+		{"", 3, ""},
+		{"1", 1, ""},
+		{"3", 3, ""},
+		{"4", 0, "must not exceed 3"},
+		{"0", 0, errorInvalidForecastDays},
+		{"-2", 0, errorInvalidForecastDays},
+		{"abc", 0, errorInvalidForecastDays},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			days, err := parseForecastDays(tc.raw)
+
+			if tc.expectedError == "" {
+				if err != nil || days != tc.expectedDays {
+					t.Errorf("got (%d, %v) want (%d, nil)", days, err, tc.expectedDays)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestParseForecastDays_DefaultRespectsLowerTier(t *testing.T) {
+	setup()
+	defer teardown()
+
+	forecastMaxDays = 1
+
+	if days, err := parseForecastDays(""); err != nil || days != 1 {
+		t.Errorf("expected default to be clamped to 1 day, got (%d, %v)", days, err)
+	}
+}
+
+func TestForecastHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockForecastResponse = `{"location": {"tz_id": "America/Sao_Paulo"}, "forecast": {"forecastday": [
+		{"date": "2025-04-21", "day": {"maxtemp_c": 27.5, "mintemp_c": 17.0}},
+		{"date": "2025-04-22", "day": {"maxtemp_c": 25.0, "mintemp_c": 16.2}},
+		{"date": "2025-04-23", "day": {"maxtemp_c": 22.1, "mintemp_c": -1.5}}
+	]}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/forecast", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	if mockForecastLastDays != "3" {
+		t.Errorf("expected the default of 3 days to be requested, got %q", mockForecastLastDays)
+	}
+
+	var forecasts []DailyForecast
+	if err := json.NewDecoder(rr.Body).Decode(&forecasts); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if len(forecasts) != 3 {
+		t.Fatalf("expected 3 days, got %d", len(forecasts))
+	}
+
+	expected := DailyForecast{Date: "2025-04-21", MinTempC: 17.0, MaxTempC: 27.5, MinTempF: 62.6, MaxTempF: 81.5, MinTempK: 290.0, MaxTempK: 300.5}
+	if forecasts[0] != expected {
+		t.Errorf("got %+v want %+v", forecasts[0], expected)
+	}
+	if last := forecasts[2]; last.MinTempC != -1.5 || last.MinTempF != 29.3 || last.MinTempK != 271.5 {
+		t.Errorf("unexpected conversions for negative minimum: %+v", last)
+	}
+}
+
+func TestForecastHandler_DaysParam(t *testing.T) {
+	setup()
+	defer teardown()
+
+	forecastMaxDays = maxForecastDays
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockForecastResponse = `{"forecast": {"forecastday": []}}`
+
+	testCases := []struct {
+		query        string
+		expectedCode int
+		expectedDays string
+	}{
+		{"?days=1", http.StatusOK, "1"},
+		{"?days=10", http.StatusOK, "10"},
+		{"?days=11", http.StatusUnprocessableEntity, ""},
+		{"?days=0", http.StatusUnprocessableEntity, ""},
+		{"?days=abc", http.StatusUnprocessableEntity, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			mockForecastLastDays = ""
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000/forecast"+tc.query, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v (body %s)", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if mockForecastLastDays != tc.expectedDays {
+				t.Errorf("expected days=%q upstream, got %q", tc.expectedDays, mockForecastLastDays)
+			}
+		})
+	}
+}
+
+func TestForecastHandler_InvalidCEP(t *testing.T) {
+	setup()
+	defer teardown()
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/123/forecast", nil))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls, got %d", calls)
+	}
+}
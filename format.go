@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// namingCamel é o valor de ?naming= que troca as chaves do JSON para camelCase (temp_C -> tempC)
+const namingCamel = "camel"
+
+// Formatos de resposta suportados
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+	formatText = "text" // Uma linha legível no terminal, disponível apenas em /weather/{cep}
+)
+
+// ErrorResponse Struct para erros no formato XML
+type ErrorResponse struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Message string   `json:"error" xml:"message"`
+}
+
+// acceptedMediaTypes mapeia os tipos do cabeçalho Accept para os formatos suportados.
+// Curingas (*/*) não escolhem formato: sem preferência explícita, vale o JSON.
+var acceptedMediaTypes = map[string]string{
+	"application/json": formatJSON,
+	"application/xml":  formatXML,
+	"text/xml":         formatXML,
+	"text/plain":       formatText,
+}
+
+// responseFormat define o formato da resposta: ?format= tem prioridade sobre o cabeçalho Accept.
+// No Accept vence o tipo suportado com maior peso (q), e em caso de empate o primeiro listado;
+// q=0 exclui o tipo. JSON é o padrão.
+func responseFormat(r *http.Request) string {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case formatXML:
+		return formatXML
+	case formatJSON:
+		return formatJSON
+	case formatText:
+		return formatText
+	}
+
+	best, bestWeight := formatJSON, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		format, ok := acceptedMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		weight := 1.0
+		if raw, ok := params["q"]; ok {
+			if weight, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if weight > bestWeight {
+			best, bestWeight = format, weight
+		}
+	}
+	return best
+}
+
+// writeResponse envia o corpo no formato negociado com o cliente. Com ?naming=camel, as
+// chaves do JSON passam para camelCase; com ?canonical=true, são ordenadas alfabeticamente
+// em todos os níveis. Corpos sem representação em texto puro são enviados em JSON.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Add("Vary", "Accept") // O formato depende do Accept; caches não devem misturá-los
+	if responseFormat(r) == formatXML {
+		writeXML(w, r, status, body)
+		return
+	}
+	if strings.EqualFold(r.URL.Query().Get("naming"), namingCamel) {
+		camel, err := camelCaseJSON(body)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error encoding camelCase JSON response", "error", err)
+			setCacheControl(w, http.StatusInternalServerError)
+			http.Error(w, errorInternalServer, http.StatusInternalServerError)
+			return
+		}
+		body = camel
+	}
+	if queryBool(r, "canonical") {
+		canonical, err := canonicalJSON(body)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error encoding canonical JSON response", "error", err)
+			setCacheControl(w, http.StatusInternalServerError)
+			http.Error(w, errorInternalServer, http.StatusInternalServerError)
+			return
+		}
+		body = canonical
+	}
+	writeJSON(r.Context(), w, status, body)
+}
+
+// canonicalJSON converte o corpo em mapas genéricos, que o encoding/json serializa com as
+// chaves ordenadas. Os números são preservados como escritos (json.Number), sem perda de precisão.
+func canonicalJSON(body any) (any, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var canonical any
+	if err := decoder.Decode(&canonical); err != nil {
+		return nil, err
+	}
+	return canonical, nil
+}
+
+// camelCaseJSON reescreve as chaves de todos os objetos do corpo em camelCase, mantendo a
+// ordem dos campos e os números como escritos. As chaves padrão continuam em snake_case
+// por compatibilidade com os clientes existentes.
+func camelCaseJSON(body any) (json.RawMessage, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	if err := writeCamelCaseValue(decoder, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeCamelCaseValue copia o próximo valor do decoder para out, renomeando as chaves dos objetos
+func writeCamelCaseValue(decoder *json.Decoder, out *bytes.Buffer) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		encoded, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		return nil
+	}
+
+	object := delim == '{'
+	out.WriteRune(rune(delim))
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if object {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			encoded, err := json.Marshal(camelCase(key.(string)))
+			if err != nil {
+				return err
+			}
+			out.Write(encoded)
+			out.WriteByte(':')
+		}
+		if err := writeCamelCaseValue(decoder, out); err != nil {
+			return err
+		}
+	}
+	end, err := decoder.Token() // '}' ou ']'
+	if err != nil {
+		return err
+	}
+	out.WriteRune(rune(end.(json.Delim)))
+	return nil
+}
+
+// camelCase converte uma chave snake_case: "temp_C" -> "tempC", "feels_like_C" -> "feelsLikeC"
+func camelCase(key string) string {
+	parts := strings.Split(key, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// writeError envia uma mensagem de erro. No formato padrão mantém o texto puro de http.Error;
+// no modo XML o erro é envolvido em <error><message>...</message></error>. A mensagem é
+// traduzida conforme o Accept-Language (ex: pt-BR), com o inglês como padrão.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Language")
+	message, language := localizeMessage(r, message)
+	w.Header().Set("Content-Language", language)
+	if responseFormat(r) == formatXML {
+		writeXML(w, r, status, ErrorResponse{Message: message})
+		return
+	}
+	setCacheControl(w, status)
+	http.Error(w, message, status)
+}
+
+// writeText envia uma resposta em texto puro, terminada por quebra de linha
+func writeText(w http.ResponseWriter, status int, line string) {
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	setCacheControl(w, status)
+	w.WriteHeader(status)
+	io.WriteString(w, line+"\n")
+}
+
+// weatherText formata a temperatura como uma linha para o terminal.
+// Ex: "São Paulo: 25.5°C / 77.9°F / 298.5K"
+func weatherText(city string, response WeatherResponse) string {
+	return fmt.Sprintf("%s: %s°C / %s°F / %sK", city,
+		formatNumber(response.TempC), formatNumber(response.TempF), formatNumber(response.TempK))
+}
+
+// formatNumber escreve o número como no JSON, sem zeros à direita
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// writeXML envia uma resposta XML com o status informado
+func writeXML(w http.ResponseWriter, r *http.Request, status int, body any) {
+	output, err := xml.Marshal(body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error encoding XML response", "error", err)
+		setCacheControl(w, http.StatusInternalServerError)
+		http.Error(w, errorInternalServer, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	setCacheControl(w, status)
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(output)
+}
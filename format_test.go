@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResponseFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		accept   string
+		expected string
+	}{
+		{"default", "/weather/01001000", "", formatJSON},
+		{"wildcard accept", "/weather/01001000", "*/*", formatJSON},
+		{"accept xml", "/weather/01001000", "application/xml", formatXML},
+		{"accept text xml with params", "/weather/01001000", "text/xml; charset=utf-8", formatXML},
+		{"accept json first", "/weather/01001000", "application/json, application/xml", formatJSON},
+		{"xml preferred by weight", "/weather/01001000", "application/json;q=0.5, application/xml", formatXML},
+		{"json preferred by weight", "/weather/01001000", "application/xml;q=0.8, application/json;q=0.9", formatJSON},
+		{"xml excluded with q=0", "/weather/01001000", "application/xml;q=0", formatJSON},
+		{"browser accept", "/weather/01001000", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatXML},
+		{"invalid weight ignored", "/weather/01001000", "application/xml;q=abc", formatJSON},
+		{"accept text", "/weather/01001000", "text/plain", formatText},
+		{"query param", "/weather/01001000?format=xml", "", formatXML},
+		{"query param text", "/weather/01001000?format=text", "application/json", formatText},
+		{"query param wins over accept", "/weather/01001000?format=json", "application/xml", formatJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			if format := responseFormat(req); format != tc.expected {
+				t.Errorf("got %q want %q", format, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_TextResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	for _, accept := range []string{"text/plain", ""} {
+		target := "/weather/01001000"
+		if accept == "" {
+			target += "?format=text"
+		}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+
+		weatherHandler(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("%s: handler returned wrong status code: got %v want %v", target, status, http.StatusOK)
+		}
+		if ctype := rr.Header().Get("Content-Type"); ctype != "text/plain; charset=utf-8" {
+			t.Errorf("%s: handler returned wrong content type: got %s", target, ctype)
+		}
+		expected := "São Paulo: 25.5°C / 77.9°F / 298.5K\n"
+		if body := rr.Body.String(); body != expected {
+			t.Errorf("%s: handler returned unexpected body: got %q want %q", target, body, expected)
+		}
+	}
+
+	// Erros continuam em texto puro
+	req := httptest.NewRequest(http.MethodGet, "/weather/123?format=text", nil)
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity || strings.TrimSpace(rr.Body.String()) != errorInvalidZipcode {
+		t.Errorf("got status %v body %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestWeatherHandler_XMLResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if ctype := rr.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "application/xml") {
+		t.Errorf("handler returned wrong content type: got %s", ctype)
+	}
+
+	var actualResponse WeatherResponse
+	if err := xml.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+		t.Fatalf("Could not decode XML response body: %v", err)
+	}
+
+	expectedResponse := WeatherResponse{
+		XMLName: xml.Name{Local: "weather"},
+		TempC:   25.5,
+		TempF:   celsiusToFahrenheit(25.5),
+		TempK:   celsiusToKelvin(25.5),
+	}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("handler returned unexpected body: got %+v want %+v", actualResponse, expectedResponse)
+	}
+}
+
+func TestWeatherHandler_XMLElementStructure(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5, "feelslike_c": 27.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?fields=feelslike&timing=true", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, application/xml")
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if vary := rr.Header().Values("Vary"); !slices.Contains(vary, "Accept") {
+		t.Errorf("expected Vary: Accept, got %v", vary)
+	}
+
+	// Percorre os elementos, registrando o caminho de cada um a partir da raiz
+	var paths, stack []string
+	decoder := xml.NewDecoder(rr.Body)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Could not parse XML response body: %v", err)
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, element.Name.Local)
+			paths = append(paths, strings.Join(stack, "/"))
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	expected := []string{
+		"weather",
+		"weather/temp_C",
+		"weather/temp_F",
+		"weather/temp_K",
+		"weather/timings",
+		"weather/timings/viacep_ms",
+		"weather/timings/weatherapi_ms",
+		"weather/feels_like",
+		"weather/feels_like/temp_C",
+		"weather/feels_like/temp_F",
+		"weather/feels_like/temp_K",
+	}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("unexpected XML structure:\ngot  %v\nwant %v", paths, expected)
+	}
+}
+
+func TestWeatherHandler_XMLError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/123?format=xml", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+
+	var errorResponse ErrorResponse
+	if err := xml.NewDecoder(rr.Body).Decode(&errorResponse); err != nil {
+		t.Fatalf("Could not decode XML error body: %v", err)
+	}
+	if errorResponse.XMLName.Local != "error" || errorResponse.Message != errorInvalidZipcode {
+		t.Errorf("unexpected XML error: %+v", errorResponse)
+	}
+}
+
+func TestBatchHandler_XMLResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch?format=xml", strings.NewReader(`["01001000", "123"]`))
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	var results struct {
+		XMLName xml.Name      `xml:"results"`
+		Results []BatchResult `xml:"result"`
+	}
+	if err := xml.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode XML batch body: %v", err)
+	}
+	if len(results.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results.Results))
+	}
+	if results.Results[0].Status != batchStatusOK || results.Results[0].Weather == nil {
+		t.Errorf("unexpected first result: %+v", results.Results[0])
+	}
+	if results.Results[1].Status != batchStatusInvalid {
+		t.Errorf("unexpected second result: %+v", results.Results[1])
+	}
+}
+
+// jsonKeys retorna as chaves do objeto JSON de nível superior, na ordem em que aparecem
+func jsonKeys(t *testing.T, body []byte) []string {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		t.Fatalf("expected a JSON object, got %v (%v)", token, err)
+	}
+
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			t.Fatalf("invalid JSON value: %v", err)
+		}
+	}
+	return keys
+}
+
+func TestWeatherHandler_StableKeyOrder(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.3, "precip_mm": 0.4, "humidity": 78, "wind_kph": 12.6}, "location": {"region": "Sao Paulo", "country": "Brazil"}}`
+
+	doRequest := func(query string) []byte {
+		rr := httptest.NewRecorder()
+		weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?extended=true&fields=wind,humidity"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+		}
+		return rr.Body.Bytes()
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"declared order", "", []string{"temp_C", "temp_F", "temp_K", "precip_mm", "region", "country", "humidity", "wind_kph", "sources"}},
+		{"canonical order", "&canonical=true", []string{"country", "humidity", "precip_mm", "region", "sources", "temp_C", "temp_F", "temp_K", "wind_kph"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			first := doRequest(tc.query)
+			if keys := jsonKeys(t, first); !slices.Equal(keys, tc.expected) {
+				t.Errorf("got key order %v want %v", keys, tc.expected)
+			}
+
+			for i := 0; i < 5; i++ {
+				if body := doRequest(tc.query); !bytes.Equal(body, first) {
+					t.Fatalf("serialization %d differs:\n%s\n%s", i+2, body, first)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalJSON_PreservesNumbers(t *testing.T) {
+	canonical, err := canonicalJSON(map[string]any{"b": 298.1, "a": []any{map[string]any{"z": 1, "y": 2}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encoded, err := json.Marshal(canonical)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"a":[{"y":2,"z":1}],"b":298.1}`; string(encoded) != expected {
+		t.Errorf("got %s want %s", encoded, expected)
+	}
+}
+
+func TestWeatherHandler_Naming(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"location": {"region": "Sao Paulo", "country": "Brazil"}, "current": {"temp_c": 25.0, "precip_mm": 1.2, "humidity": 60, "wind_kph": 10.1, "feelslike_c": 26.0}}`
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"default snake_case", "", []string{"temp_C", "temp_F", "temp_K", "precip_mm", "region", "country", "humidity", "wind_kph", "feels_like", "sources"}},
+		{"explicit snake_case", "&naming=snake", []string{"temp_C", "temp_F", "temp_K", "precip_mm", "region", "country", "humidity", "wind_kph", "feels_like", "sources"}},
+		{"camelCase", "&naming=camel", []string{"tempC", "tempF", "tempK", "precipMm", "region", "country", "humidity", "windKph", "feelsLike", "sources"}},
+		{"camelCase and canonical", "&naming=camel&canonical=true", []string{"country", "feelsLike", "humidity", "precipMm", "region", "sources", "tempC", "tempF", "tempK", "windKph"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?extended=true&fields=wind,humidity,feelslike"+tc.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if keys := jsonKeys(t, rr.Body.Bytes()); !slices.Equal(keys, tc.expected) {
+				t.Errorf("got keys %v want %v", keys, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCamelCaseJSON(t *testing.T) {
+	encoded, err := camelCaseJSON(map[string]any{
+		"min_temp_C": 17.0,
+		"hours":      []any{map[string]any{"temp_K": 298.15, "time": "a_b"}},
+		"empty":      []any{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"empty":[],"hours":[{"tempK":298.15,"time":"a_b"}],"minTempC":17}`; string(encoded) != expected {
+		t.Errorf("got %s want %s", encoded, expected)
+	}
+}
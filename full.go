@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AddressResponse Struct com o endereço completo do CEP, nos mesmos campos do ViaCEP
+type AddressResponse struct {
+	CEP         string `json:"cep" xml:"cep"`
+	Logradouro  string `json:"logradouro,omitempty" xml:"logradouro,omitempty"`
+	Complemento string `json:"complemento,omitempty" xml:"complemento,omitempty"`
+	Unidade     string `json:"unidade,omitempty" xml:"unidade,omitempty"`
+	Bairro      string `json:"bairro,omitempty" xml:"bairro,omitempty"`
+	Localidade  string `json:"localidade" xml:"localidade"`
+	UF          string `json:"uf,omitempty" xml:"uf,omitempty"`
+	Estado      string `json:"estado,omitempty" xml:"estado,omitempty"`
+	Regiao      string `json:"regiao,omitempty" xml:"regiao,omitempty"`
+	IBGE        string `json:"ibge,omitempty" xml:"ibge,omitempty"`
+	GIA         string `json:"gia,omitempty" xml:"gia,omitempty"`
+	DDD         string `json:"ddd,omitempty" xml:"ddd,omitempty"`
+	SIAFI       string `json:"siafi,omitempty" xml:"siafi,omitempty"`
+}
+
+// FullWeatherResponse Struct para a resposta de /weather/{cep}/full: endereço e clima juntos
+type FullWeatherResponse struct {
+	XMLName xml.Name         `json:"-" xml:"full"`
+	Address AddressResponse  `json:"address" xml:"address"`
+	Weather *WeatherResponse `json:"weather,omitempty" xml:"weather,omitempty"` // Ausente com ?only_city=true
+}
+
+// newAddressResponse monta o endereço da resposta. CEPs vindos da base local não têm os
+// demais campos do ViaCEP, e o endereço traz apenas o CEP, a cidade e a UF.
+func newAddressResponse(cep string, location cepLocation) AddressResponse {
+	if location.Address == nil {
+		return AddressResponse{CEP: cep[:5] + "-" + cep[5:], Localidade: location.City, UF: location.UF}
+	}
+	address := location.Address
+	return AddressResponse{
+		CEP:         address.CEP,
+		Logradouro:  address.Logradouro,
+		Complemento: address.Complemento,
+		Unidade:     address.Unidade,
+		Bairro:      address.Bairro,
+		Localidade:  address.Localidade,
+		UF:          address.UF,
+		Estado:      address.Estado,
+		Regiao:      address.Regiao,
+		IBGE:        address.IBGE,
+		GIA:         address.GIA,
+		DDD:         address.DDD,
+		SIAFI:       address.SIAFI,
+	}
+}
+
+// fullWeatherHandler atende GET /weather/{cep}/full, devolvendo o endereço completo do
+// ViaCEP junto com as temperaturas. Os parâmetros de query são os mesmos de /weather/{cep}:
+// ?only_city=true devolve apenas o endereço, sem consultar a WeatherAPI. ?unit= é recusado
+// e o formato texto não se aplica, pois a resposta sempre traz o endereço.
+func fullWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	cep := r.PathValue("cep")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("cep", cep))
+
+	if r.URL.Query().Get("unit") != "" {
+		writeError(w, r, http.StatusUnprocessableEntity, errorUnitNotSupported) // 422
+		return
+	}
+
+	result, ok := resolveCEPWeather(w, r, cep)
+	if !ok {
+		return
+	}
+
+	full := FullWeatherResponse{Address: newAddressResponse(cep, result.Location), Weather: result.Weather}
+	writeWithETag(w, r, func(w http.ResponseWriter) {
+		writeResponse(w, r, http.StatusOK, full) // 200
+	})
+}
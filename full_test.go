@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFullWeatherHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"cep": "01001-000", "logradouro": "Praça da Sé", "complemento": "lado ímpar", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP", "estado": "São Paulo", "regiao": "Sudeste", "ibge": "3550308", "gia": "1004", "ddd": "11", "siafi": "7107"}`
+	mockWeatherAPIResponse = `{"location": {"name": "São Paulo"}, "current": {"temp_c": 25.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	var response FullWeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	expectedAddress := AddressResponse{
+		CEP: "01001-000", Logradouro: "Praça da Sé", Complemento: "lado ímpar", Bairro: "Sé",
+		Localidade: "São Paulo", UF: "SP", Estado: "São Paulo", Regiao: "Sudeste",
+		IBGE: "3550308", GIA: "1004", DDD: "11", SIAFI: "7107",
+	}
+	if response.Address != expectedAddress {
+		t.Errorf("unexpected address: got %+v want %+v", response.Address, expectedAddress)
+	}
+	if w := response.Weather; w.TempC != 25.0 || w.TempF != 77.0 || w.TempK != 298.0 {
+		t.Errorf("unexpected temperatures: %+v", w)
+	}
+}
+
+func TestFullWeatherHandler_XML(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	var response FullWeatherResponse
+	if err := xml.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode XML body: %v", err)
+	}
+	if response.Address.Logradouro != "Praça da Sé" || response.Weather.TempC != 25.0 {
+		t.Errorf("unexpected XML response: %+v", response)
+	}
+}
+
+func TestFullWeatherHandler_CachedCEPKeepsAddress(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	mockViaCEPResponse = `{"cep": "01001-000", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full", nil)
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, req)
+
+		var response FullWeatherResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+		if response.Address.Bairro != "Sé" {
+			t.Errorf("request %d: expected the neighbourhood to be present, got %+v", i, response.Address)
+		}
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected the second request to be served from the CEP cache, got %d ViaCEP calls", calls)
+	}
+}
+
+func TestFullWeatherHandler_LocalCEPDatabase(t *testing.T) {
+	setup()
+	defer teardown()
+
+	db, err := loadCEPDatabase(writeCEPDatabase(t, "69900000,Rio Branco,AC\n"))
+	if err != nil {
+		t.Fatalf("failed to load database: %v", err)
+	}
+	localCEPDB = db
+	mockWeatherAPIResponse = `{"current": {"temp_c": 30.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/69900000/full", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	var response FullWeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	expected := AddressResponse{CEP: "69900-000", Localidade: "Rio Branco", UF: "AC"}
+	if response.Address != expected {
+		t.Errorf("unexpected address: got %+v want %+v", response.Address, expected)
+	}
+}
+
+func TestFullWeatherHandler_Errors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"erro": true}`
+
+	for path, want := range map[string]int{
+		"/weather/123/full":      http.StatusUnprocessableEntity,
+		"/weather/01001000/full": http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("%s: got status %d want %d", path, rr.Code, want)
+		}
+	}
+}
+
+func TestFullWeatherHandler_OnlyCity(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"cep": "01001-000", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP"}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full?only_city=true", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+	expected := `{"address":{"cep":"01001-000","bairro":"Sé","localidade":"São Paulo","uf":"SP"}}`
+	if body := strings.TrimSpace(rr.Body.String()); body != expected {
+		t.Errorf("got body %s want %s", body, expected)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 0 {
+		t.Errorf("expected no WeatherAPI calls with only_city, got %d", calls)
+	}
+}
+
+func TestFullWeatherHandler_UnitRejected(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full?unit=f", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got status %d want %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorUnitNotSupported {
+		t.Errorf("got body %q want %q", body, errorUnitNotSupported)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls, got %d", calls)
+	}
+}
+
+func TestFullWeatherHandler_CacheKeyHeader(t *testing.T) {
+	setup()
+	defer teardown()
+
+	debugEndpoints = true
+	mockViaCEPResponse = `{"cep": "01001-000", "localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/full", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if key, expected := rr.Header().Get(cacheKeyHeader), "são paulo,sp,brazil"; key != expected {
+		t.Errorf("got %s %q want %q", cacheKeyHeader, key, expected)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	gzipMinSizeEnv     = "GZIP_MIN_SIZE"
+	defaultGzipMinSize = 512 // Abaixo disso o overhead do gzip não compensa
+)
+
+// gzipMinSize é o tamanho mínimo (em bytes) de uma resposta para que ela seja comprimida
+var gzipMinSize = defaultGzipMinSize
+
+// bufferedResponseWriter acumula a resposta em memória para que um middleware possa
+// processá-la por inteiro antes de enviá-la (ex: decidir se vale comprimir)
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (g *bufferedResponseWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
+
+func (g *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	return g.buf.Write(b)
+}
+
+// withGzip comprime com gzip as respostas quando o cliente envia Accept-Encoding: gzip
+// e o corpo atinge o tamanho mínimo configurado
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(gw, r)
+		if gw.status == 0 {
+			gw.status = http.StatusOK
+		}
+
+		if gw.buf.Len() < gzipMinSize || w.Header().Get("Content-Encoding") != "" {
+			w.WriteHeader(gw.status)
+			w.Write(gw.buf.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(gw.status)
+
+		gz := gzip.NewWriter(w)
+		if _, err := gz.Write(gw.buf.Bytes()); err != nil {
+			slog.ErrorContext(r.Context(), "Error writing gzip response", "error", err)
+		}
+		if err := gz.Close(); err != nil {
+			slog.ErrorContext(r.Context(), "Error closing gzip response", "error", err)
+		}
+	})
+}
+
+// acceptsGzip verifica se o cliente aceita gzip, respeitando "gzip;q=0" como recusa
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
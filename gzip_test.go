@@ -0,0 +1,87 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouter_GzipCompression(t *testing.T) {
+	setup()
+	defer teardown()
+
+	gzipMinSize = 0 // Comprime qualquer resposta para este teste
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding, got %q", encoding)
+	}
+
+	reader, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("response is not valid gzip: %v", err)
+	}
+	var actualResponse WeatherResponse
+	if err := json.NewDecoder(reader).Decode(&actualResponse); err != nil {
+		t.Fatalf("Could not decode decompressed body: %v", err)
+	}
+
+	expectedResponse := WeatherResponse{TempC: 25.5, TempF: celsiusToFahrenheit(25.5), TempK: celsiusToKelvin(25.5)}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("unexpected body: got %+v want %+v", actualResponse, expectedResponse)
+	}
+}
+
+func TestRouter_GzipSkipped(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		minSize        int
+	}{
+		{"client without gzip support", "", 0},
+		{"client refuses gzip", "gzip;q=0", 0},
+		{"payload below threshold", "gzip", 1024},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gzipMinSize = tc.minSize
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+
+			newRouter().ServeHTTP(rr, req)
+
+			if encoding := rr.Header().Get("Content-Encoding"); encoding != "" {
+				t.Errorf("expected uncompressed response, got Content-Encoding %q", encoding)
+			}
+			if !strings.Contains(rr.Body.String(), `"temp_C":25.5`) {
+				t.Errorf("unexpected plain body: %s", rr.Body.String())
+			}
+		})
+	}
+}
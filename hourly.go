@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	forecastDateLayout = "2006-01-02"
+	forecastHourLayout = "2006-01-02 15:04" // Formato do campo "time" da WeatherAPI
+)
+
+// HourlyForecastResponse Struct para a resposta de /weather/{cep}/hourly e /forecast/{cep}/hourly
+type HourlyForecastResponse struct {
+	XMLName  xml.Name            `json:"-" xml:"hourly_forecast"`
+	Date     string              `json:"date" xml:"date"`                             // Dia efetivamente retornado, no fuso da localização
+	Timezone string              `json:"timezone,omitempty" xml:"timezone,omitempty"` // Ex: "America/Sao_Paulo"
+	Hours    []HourlyTemperature `json:"hours" xml:"hour"`
+}
+
+// HourlyTemperature Struct para a temperatura prevista em um horário
+type HourlyTemperature struct {
+	Time  string  `json:"time" xml:"time"` // RFC 3339 com o deslocamento do fuso da localização
+	TempC float64 `json:"temp_C" xml:"temp_C"`
+	TempF float64 `json:"temp_F" xml:"temp_F"`
+	TempK float64 `json:"temp_K" xml:"temp_K"`
+}
+
+// hourRange é o intervalo de horas locais (inclusivo) pedido via ?from=&to=
+type hourRange struct {
+	from int
+	to   int
+}
+
+// contains informa se a hora local está dentro do intervalo
+func (h hourRange) contains(hour int) bool {
+	return hour >= h.from && hour <= h.to
+}
+
+// hourlyForecastHandler atende GET /forecast/{cep}/hourly, mantido como alias de /weather/{cep}/hourly
+func hourlyForecastHandler(w http.ResponseWriter, r *http.Request) {
+	forecastRoutes.ServeHTTP(w, r)
+}
+
+// hourlyHandler atende GET /weather/{cep}/hourly?date=yyyy-MM-dd&interval=N&from=H&to=H,
+// retornando as temperaturas previstas hora a hora (ou a cada N horas) para um dia,
+// opcionalmente restritas às horas locais entre from e to
+func hourlyHandler(w http.ResponseWriter, r *http.Request) {
+	cep := r.PathValue("cep")
+	if !isValidCEP(cep) {
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidZipcode) // 422
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if _, err := time.Parse(forecastDateLayout, date); err != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, errorInvalidDate) // 422
+			return
+		}
+	}
+
+	interval, ok := parseHourlyInterval(r.URL.Query().Get("interval"))
+	if !ok {
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidInterval) // 422
+		return
+	}
+
+	hours, ok := parseHourRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if !ok {
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidHourRange) // 422
+		return
+	}
+
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	location, _, err := lookupCEP(ctx, defaultClients, cep, true)
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting city from CEP", "cep", cep, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	// Busca todos os dias permitidos pelo plano, já que "hoje" depende do fuso da localização
+	forecast, err := getForecastForLocation(ctx, defaultClients, location, forecastMaxDays)
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting forecast for city", "cep", cep, "city", location.City, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	day, ok := selectForecastDay(forecast.Forecast.ForecastDay, date)
+	if !ok {
+		slog.ErrorContext(ctx, "WeatherAPI returned an empty forecast", "cep", cep, "city", location.City)
+		writeError(w, r, http.StatusInternalServerError, errorInternalServer)
+		return
+	}
+	if date != "" && day.Date != date {
+		slog.InfoContext(ctx, "Forecast date clamped to the available range", "cep", cep, "requested", date, "date", day.Date)
+	}
+
+	writeResponse(w, r, http.StatusOK, newHourlyForecastResponse(day, forecast.Location.TzID, interval, hours))
+}
+
+// parseHourlyInterval valida o parâmetro ?interval= (em horas, padrão 1)
+func parseHourlyInterval(raw string) (int, bool) {
+	if raw == "" {
+		return 1, true
+	}
+	interval, err := strconv.Atoi(raw)
+	if err != nil || interval < 1 || interval > 24 {
+		return 0, false
+	}
+	return interval, true
+}
+
+// parseHourRange valida ?from= e ?to= (horas de 0 a 23, inclusivas). Sem os parâmetros,
+// o intervalo cobre o dia inteiro.
+func parseHourRange(rawFrom, rawTo string) (hourRange, bool) {
+	hours := hourRange{from: 0, to: 23}
+	for _, param := range []struct {
+		raw    string
+		target *int
+	}{{rawFrom, &hours.from}, {rawTo, &hours.to}} {
+		if param.raw == "" {
+			continue
+		}
+		hour, err := strconv.Atoi(param.raw)
+		if err != nil || hour < 0 || hour > 23 {
+			return hourRange{}, false
+		}
+		*param.target = hour
+	}
+	if hours.from > hours.to {
+		return hourRange{}, false
+	}
+	return hours, true
+}
+
+// selectForecastDay escolhe o dia da previsão correspondente à data pedida. Datas fora do
+// intervalo disponível são ajustadas para o primeiro ou o último dia; sem data, usa o primeiro
+// dia, que é "hoje" no fuso da localização.
+func selectForecastDay(days []WeatherAPIForecastDay, date string) (WeatherAPIForecastDay, bool) {
+	if len(days) == 0 {
+		return WeatherAPIForecastDay{}, false
+	}
+	// Datas yyyy-MM-dd podem ser comparadas como texto
+	for _, day := range days {
+		if day.Date >= date {
+			return day, true
+		}
+	}
+	return days[len(days)-1], true
+}
+
+// newHourlyForecastResponse converte as horas do dia dentro do intervalo pedido para a nossa
+// resposta, uma a cada interval horas a partir da primeira, com os horários no fuso da localização
+func newHourlyForecastResponse(day WeatherAPIForecastDay, tzID string, interval int, hours hourRange) HourlyForecastResponse {
+	loc := time.UTC
+	if tzID != "" {
+		if tz, err := time.LoadLocation(tzID); err == nil {
+			loc = tz
+		}
+	}
+
+	response := HourlyForecastResponse{Date: day.Date, Timezone: tzID, Hours: []HourlyTemperature{}}
+	selected := 0
+	for _, hour := range day.Hour {
+		// O horário local é a referência; o epoch cobre respostas sem o campo "time"
+		at, err := time.ParseInLocation(forecastHourLayout, hour.Time, loc)
+		if err != nil {
+			at = time.Unix(hour.TimeEpoch, 0).In(loc)
+		}
+		if !hours.contains(at.Hour()) {
+			continue
+		}
+		selected++
+		if (selected-1)%interval != 0 {
+			continue
+		}
+
+		response.Hours = append(response.Hours, HourlyTemperature{
+			Time:  at.Format(time.RFC3339),
+			TempC: hour.TempC,
+			TempF: temperatureConverter.Fahrenheit(hour.TempC, 1),
+			TempK: temperatureConverter.Kelvin(hour.TempC, 1),
+		})
+	}
+	return response
+}
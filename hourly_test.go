@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// buildHourlyForecast gera um payload da WeatherAPI com 24 horas por dia, em que a
+// temperatura de cada hora é base + hora
+func buildHourlyForecast(tzID string, dates []string, base float64) string {
+	var days []string
+	for _, date := range dates {
+		var hours []string
+		for h := 0; h < 24; h++ {
+			hours = append(hours, fmt.Sprintf(`{"time": "%s %02d:00", "temp_c": %.1f}`, date, h, base+float64(h)))
+		}
+		days = append(days, fmt.Sprintf(`{"date": "%s", "day": {"maxtemp_c": %.1f, "mintemp_c": %.1f}, "hour": [%s]}`,
+			date, base+23, base, strings.Join(hours, ",")))
+	}
+	return fmt.Sprintf(`{"location": {"tz_id": "%s"}, "forecast": {"forecastday": [%s]}}`, tzID, strings.Join(days, ","))
+}
+
+func TestHourlyForecastHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockForecastResponse = buildHourlyForecast("America/Sao_Paulo", []string{"2025-04-21", "2025-04-22", "2025-04-23"}, 10)
+
+	doRequest := func(query string) (*httptest.ResponseRecorder, HourlyForecastResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/forecast/01001000/hourly"+query, nil)
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, req)
+
+		var response HourlyForecastResponse
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+		}
+		return rr, response
+	}
+
+	rr, response := doRequest("")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if response.Date != "2025-04-21" || len(response.Hours) != 24 {
+		t.Fatalf("expected 24 entries for the first day, got %d entries for %s", len(response.Hours), response.Date)
+	}
+	// 13h local: 10 + 13 = 23°C
+	expected := HourlyTemperature{Time: "2025-04-21T13:00:00-03:00", TempC: 23, TempF: 73.4, TempK: 296}
+	if response.Hours[13] != expected {
+		t.Errorf("got %+v want %+v", response.Hours[13], expected)
+	}
+
+	testCases := []struct {
+		query        string
+		expectedDate string
+		expectedLen  int
+	}{
+		{"?date=2025-04-22", "2025-04-22", 24},
+		{"?date=2025-04-22&interval=3", "2025-04-22", 8},
+		{"?date=2025-01-01", "2025-04-21", 24}, // Antes do intervalo: ajustada para o primeiro dia
+		{"?date=2026-01-01", "2025-04-23", 24}, // Depois do intervalo: ajustada para o último dia
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rr, response := doRequest(tc.query)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+			}
+			if response.Date != tc.expectedDate || len(response.Hours) != tc.expectedLen {
+				t.Errorf("got %d entries for %s, want %d entries for %s", len(response.Hours), response.Date, tc.expectedLen, tc.expectedDate)
+			}
+		})
+	}
+}
+
+func TestWeatherHourlyHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockForecastResponse = buildHourlyForecast("America/Sao_Paulo", []string{"2025-04-21", "2025-04-22"}, 10)
+
+	testCases := []struct {
+		query         string
+		expectedTimes []string
+	}{
+		{"?from=6&to=9", []string{"06:00", "07:00", "08:00", "09:00"}},
+		{"?from=6&to=12&interval=3", []string{"06:00", "09:00", "12:00"}},
+		{"?from=22", []string{"22:00", "23:00"}},
+		{"?to=1", []string{"00:00", "01:00"}},
+		{"?from=15&to=15", []string{"15:00"}},
+	}
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000/hourly", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var full HourlyForecastResponse
+	if err := json.NewDecoder(rr.Body).Decode(&full); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if full.Date != "2025-04-21" || len(full.Hours) != 24 {
+		t.Fatalf("expected 24 entries for the first day, got %d entries for %s", len(full.Hours), full.Date)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000/hourly"+tc.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			var response HourlyForecastResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+
+			var times []string
+			for _, hour := range response.Hours {
+				times = append(times, strings.TrimSuffix(strings.TrimPrefix(hour.Time, "2025-04-21T"), ":00-03:00"))
+			}
+			if strings.Join(times, ",") != strings.Join(tc.expectedTimes, ",") {
+				t.Errorf("got hours %v want %v", times, tc.expectedTimes)
+			}
+			// As entradas filtradas são as mesmas do dia inteiro (temperatura = 10 + hora local)
+			for _, hour := range response.Hours {
+				if entry := full.Hours[int(hour.TempC)-10]; hour != entry {
+					t.Errorf("got %+v want the full-day entry %+v", hour, entry)
+				}
+			}
+		})
+	}
+}
+
+func TestHourlyForecastHandler_InvalidParams(t *testing.T) {
+	testCases := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/forecast/123/hourly", http.StatusUnprocessableEntity, errorInvalidZipcode},
+		{"/forecast/01001000/hourly?date=21/04/2025", http.StatusUnprocessableEntity, errorInvalidDate},
+		{"/forecast/01001000/hourly?interval=0", http.StatusUnprocessableEntity, errorInvalidInterval},
+		{"/forecast/01001000/hourly?interval=25", http.StatusUnprocessableEntity, errorInvalidInterval},
+		{"/forecast/01001000/daily", http.StatusNotFound, "Usage: /forecast/{cep}/hourly"},
+		{"/weather/01001000/hourly?from=24", http.StatusUnprocessableEntity, errorInvalidHourRange},
+		{"/weather/01001000/hourly?to=-1", http.StatusUnprocessableEntity, errorInvalidHourRange},
+		{"/weather/01001000/hourly?from=10&to=5", http.StatusUnprocessableEntity, errorInvalidHourRange},
+		{"/weather/01001000/hourly?from=abc", http.StatusUnprocessableEntity, errorInvalidHourRange},
+		{"/weather/123/hourly", http.StatusUnprocessableEntity, errorInvalidZipcode},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v", rr.Code, tc.expectedCode)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("got body '%s' want '%s'", body, tc.expectedBody)
+			}
+			if calls := mockForecastCalls.Load(); calls != 0 {
+				t.Errorf("expected validation to fail before calling WeatherAPI, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestHourlyForecastHandler_CEPNotFound(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"erro": true}`
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/forecast/99999999/hourly", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Idiomas das mensagens de erro. O inglês é o padrão e corresponde às constantes error*.
+const (
+	languageEnglish    = "en"
+	languagePortuguese = "pt-BR"
+)
+
+// messageCatalog traduz as mensagens de erro por idioma, indexadas pelo texto em inglês.
+// Mensagens sem tradução (ex: erros com detalhes dinâmicos) são enviadas em inglês.
+var messageCatalog = map[string]map[string]string{
+	languagePortuguese: {
+		errorInvalidZipcode:      "CEP inválido",
+		errorMalformedPath:       "caminho malformado, esperado /weather/{cep}",
+		errorMalformedResolve:    "caminho malformado, esperado /resolve/{cep}",
+		errorURITooLong:          "URI muito longa",
+		errorInvalidPathChars:    "o caminho contém caracteres de controle",
+		errorNoCoordinates:       "sem coordenadas disponíveis para a Open-Meteo",
+		errorProviderUnavailable: "provedor indisponível",
+		errorCEPNotAllowed:       "CEP não atendido por esta instalação",
+		errorCannotFindZip:       "CEP não encontrado",
+		errorCannotFindCity:      "cidade não encontrada",
+		errorEmptyCityName:       "o nome da cidade não pode ser vazio",
+		errorNoWeatherStation:    "nenhuma estação meteorológica próxima a essas coordenadas",
+		errorInternalServer:      "erro interno do servidor",
+		errorMissingAPIKey:       "chave da WeatherAPI não configurada",
+		errorTooManyAttempts:     "excesso de tentativas nos provedores externos",
+		errorInvalidFields:       "campos inválidos",
+		errorInvalidScales:       "escalas inválidas",
+		errorInvalidUnit:         "unidade inválida",
+		errorUnitNotSupported:    "unit não é suportado na resposta completa",
+		errorInvalidBaseline:     "baseline_c deve ser um número",
+		errorInvalidPrecision:    "precision deve ser um inteiro entre 0 e 3",
+		errorInvalidBatchBody:    "o corpo da requisição deve ser um array JSON de CEPs",
+		errorInvalidForecastDays: "days deve ser um inteiro positivo",
+		errorRateLimited:         "limite de requisições excedido",
+		errorInvalidDate:         "date deve estar no formato AAAA-MM-DD",
+		errorInvalidInterval:     "interval deve ser um inteiro entre 1 e 24",
+		errorInvalidHourRange:    "from e to devem ser horas entre 0 e 23, com from <= to",
+		errorInvalidTimeout:      "timeout deve ser uma duração positiva (ex: 3s)",
+		errorCircuitOpen:         "provedor de clima temporariamente indisponível",
+		errorUpstreamRateLimited: "limite de requisições do provedor de clima excedido, tente novamente mais tarde",
+		errorViaCEPRateLimited:   "limite de requisições do provedor de CEP excedido, tente novamente mais tarde",
+		errorUpstreamBusy:        "excesso de requisições simultâneas aos provedores externos, tente novamente mais tarde",
+		errorRequestTimeout:      "prazo da requisição aos provedores externos esgotado",
+		errorImpossibleTemp:      "o provedor de clima retornou uma temperatura abaixo do zero absoluto",
+		errorBadUpstreamResponse: "resposta inválida do provedor externo",
+
+		http.StatusText(http.StatusMethodNotAllowed): "método não permitido",
+	},
+}
+
+// preferredLanguage escolhe o idioma das mensagens pelo cabeçalho Accept-Language: vence o
+// idioma do catálogo com maior peso (q), e em caso de empate o primeiro listado. Qualquer
+// variante do português (pt, pt-BR, pt-PT) usa o catálogo pt-BR; sem correspondência, inglês.
+func preferredLanguage(r *http.Request) string {
+	best, bestWeight := languageEnglish, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(accepted), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		var language string
+		switch {
+		case tag == "en" || strings.HasPrefix(tag, "en-"):
+			language = languageEnglish
+		case tag == "pt" || strings.HasPrefix(tag, "pt-"):
+			language = languagePortuguese
+		default:
+			continue
+		}
+
+		weight := 1.0
+		if raw, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if weight, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if weight > bestWeight {
+			best, bestWeight = language, weight
+		}
+	}
+	return best
+}
+
+// localizeMessage traduz a mensagem de erro para o idioma preferido do cliente, quando há
+// tradução no catálogo, e informa o idioma efetivamente usado
+func localizeMessage(r *http.Request, message string) (string, string) {
+	language := preferredLanguage(r)
+	if translated, ok := messageCatalog[language][message]; ok {
+		return translated, language
+	}
+	return message, languageEnglish
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{"", languageEnglish},
+		{"pt-BR", languagePortuguese},
+		{"pt-br,pt;q=0.9,en;q=0.8", languagePortuguese},
+		{"pt", languagePortuguese},
+		{"pt-PT", languagePortuguese},
+		{"en-US,en;q=0.9,pt-BR;q=0.8", languageEnglish},
+		{"en;q=0.5, pt-BR;q=0.8", languagePortuguese},
+		{"fr-FR, pt-BR;q=0.3", languagePortuguese},
+		{"fr-FR, de", languageEnglish},
+		{"pt-BR;q=0", languageEnglish},
+		{"pt-BR;q=abc", languageEnglish},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			req.Header.Set("Accept-Language", tc.header)
+			if got := preferredLanguage(req); got != tc.expected {
+				t.Errorf("got %q want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMessageCatalog_CoversErrors(t *testing.T) {
+	for _, message := range []string{errorInvalidZipcode, errorCannotFindZip, errorMalformedPath, errorMalformedResolve, errorRateLimited, errorInternalServer} {
+		if messageCatalog[languagePortuguese][message] == "" {
+			t.Errorf("missing pt-BR translation for %q", message)
+		}
+	}
+}
+
+func TestWriteError_Localized(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"erro": true}`
+
+	testCases := []struct {
+		name             string
+		path             string
+		acceptLanguage   string
+		expectedCode     int
+		expectedBody     string
+		expectedLanguage string
+	}{
+		{"invalid CEP default", "/weather/123", "", http.StatusUnprocessableEntity, errorInvalidZipcode, languageEnglish},
+		{"invalid CEP pt-BR", "/weather/123", "pt-BR", http.StatusUnprocessableEntity, "CEP inválido", languagePortuguese},
+		{"not found default", "/weather/99999999", "", http.StatusNotFound, errorCannotFindZip, languageEnglish},
+		{"not found pt-BR", "/weather/99999999", "pt-BR,pt;q=0.9", http.StatusNotFound, "CEP não encontrado", languagePortuguese},
+		{"not found English preferred", "/weather/99999999", "en-US,pt-BR;q=0.5", http.StatusNotFound, errorCannotFindZip, languageEnglish},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v", rr.Code, tc.expectedCode)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("got body '%s' want '%s'", body, tc.expectedBody)
+			}
+			if language := rr.Header().Get("Content-Language"); language != tc.expectedLanguage {
+				t.Errorf("got Content-Language %q want %q", language, tc.expectedLanguage)
+			}
+			if vary := rr.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Accept-Language") {
+				t.Errorf("expected Vary to include Accept-Language, got %v", vary)
+			}
+		})
+	}
+}
+
+func TestWriteError_LocalizedXML(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/weather/123?format=xml", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	rr := httptest.NewRecorder()
+
+	writeError(rr, req, http.StatusUnprocessableEntity, errorInvalidZipcode)
+
+	var response ErrorResponse
+	if err := xml.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode XML body: %v", err)
+	}
+	if response.Message != "CEP inválido" {
+		t.Errorf("got message %q want %q", response.Message, "CEP inválido")
+	}
+}
+
+func TestWriteError_UntranslatedMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set("Accept-Language", "pt-BR")
+	rr := httptest.NewRecorder()
+
+	writeError(rr, req, http.StatusBadGateway, "upstream said something unexpected")
+
+	if body := strings.TrimSpace(rr.Body.String()); body != "upstream said something unexpected" {
+		t.Errorf("expected the original message, got '%s'", body)
+	}
+	if language := rr.Header().Get("Content-Language"); language != languageEnglish {
+		t.Errorf("got Content-Language %q want %q", language, languageEnglish)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// shortestDecimals usa a menor quantidade de casas que representa o valor exatamente
+const shortestDecimals = -1
+
+// temperatureDecimals define as casas decimais de temp_C, temp_F e temp_K no JSON, para que
+// a serialização siga a precisão escolhida (ex: 77.0 com 1 casa, e não 77)
+type temperatureDecimals struct {
+	Celsius    int
+	Fahrenheit int
+	Kelvin     int
+}
+
+// newTemperatureDecimals deriva as casas decimais das opções da requisição: por padrão o
+// Celsius original da WeatherAPI mantém as casas que tiver e as demais escalas usam
+// defaultPrecision; com ?precision= todas usam o valor pedido, e ?whole_kelvin=true escreve
+// Kelvin sem casas decimais
+func newTemperatureDecimals(opts responseOptions) *temperatureDecimals {
+	precision := int(opts.precision())
+	decimals := &temperatureDecimals{Celsius: shortestDecimals, Fahrenheit: precision, Kelvin: precision}
+	if opts.Precision != nil {
+		decimals.Celsius = precision
+	}
+	if opts.WholeKelvin {
+		decimals.Kelvin = 0
+	}
+	return decimals
+}
+
+// MarshalJSON escreve as temperaturas em notação decimal fixa, nunca exponencial (ex:
+// 1234567.0, e não 1.234567e+06), com as casas de temperatureDecimals. Sem elas (respostas
+// montadas fora de newWeatherResponse), usa a menor representação decimal do valor.
+func (r WeatherResponse) MarshalJSON() ([]byte, error) {
+	decimals := temperatureDecimals{Celsius: shortestDecimals, Fahrenheit: shortestDecimals, Kelvin: shortestDecimals}
+	if r.decimals != nil {
+		decimals = *r.decimals
+	}
+
+	// plain não tem o método MarshalJSON; os campos de fora têm prioridade sobre os dele
+	type plain WeatherResponse
+	return json.Marshal(struct {
+		TempC json.Number `json:"temp_C"`
+		TempF json.Number `json:"temp_F"`
+		TempK json.Number `json:"temp_K"`
+		plain
+	}{
+		TempC: formatJSONNumber(r.TempC, decimals.Celsius),
+		TempF: formatJSONNumber(r.TempF, decimals.Fahrenheit),
+		TempK: formatJSONNumber(r.TempK, decimals.Kelvin),
+		plain: plain(r),
+	})
+}
+
+// formatJSONNumber formata o número com a quantidade de casas informada, sem expoente
+func formatJSONNumber(value float64, decimals int) json.Number {
+	return json.Number(strconv.FormatFloat(value, 'f', decimals, 64))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// exponentPattern encontra números em notação exponencial (ex: 1.2e+06)
+var exponentPattern = regexp.MustCompile(`[0-9][eE][+-]?[0-9]`)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestWeatherResponse_MarshalJSONFixedDecimals(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tempC    float64
+		opts     responseOptions
+		expected string
+	}{
+		{"large value without exponent", 1234567.0, responseOptions{}, `"temp_C":1234567,"temp_F":2222252.6,"temp_K":1234840.0`},
+		{"large value with precision", 1234567.0, responseOptions{Precision: uintPtr(2)}, `"temp_C":1234567.00,"temp_F":2222252.60,"temp_K":1234840.00`},
+		{"small value", 0.1, responseOptions{}, `"temp_C":0.1,"temp_F":32.2,"temp_K":273.1`},
+		{"small value with precision", 0.1, responseOptions{Precision: uintPtr(3)}, `"temp_C":0.100,"temp_F":32.180,"temp_K":273.100`},
+		{"whole numbers keep the decimals", 25.0, responseOptions{}, `"temp_C":25,"temp_F":77.0,"temp_K":298.0`},
+		{"precision zero", 25.4, responseOptions{Precision: uintPtr(0)}, `"temp_C":25,"temp_F":78,"temp_K":298`},
+		{"whole kelvin", 25.0, responseOptions{Precision: uintPtr(2), WholeKelvin: true}, `"temp_C":25.00,"temp_F":77.00,"temp_K":298`},
+		{"tiny value without exponent", 0.0000001, responseOptions{}, `"temp_C":0.0000001,"temp_F":32.0,"temp_K":273.0`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reading := &weatherReading{WeatherAPIResponse: &WeatherAPIResponse{}}
+			reading.Current.TempC = tc.tempC
+
+			encoded, err := json.Marshal(newWeatherResponse(reading, tc.opts))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(string(encoded), "{"+tc.expected) {
+				t.Errorf("got %s want prefix {%s", encoded, tc.expected)
+			}
+			if exponentPattern.Match(encoded) {
+				t.Errorf("expected no exponent notation, got %s", encoded)
+			}
+		})
+	}
+}
+
+func TestWeatherResponse_MarshalJSONKeepsOtherFields(t *testing.T) {
+	humidity := 60
+	response := WeatherResponse{TempC: 25, TempF: 77, TempK: 298.1, Humidity: &humidity, Sources: []string{"viacep"}}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"temp_C":25,"temp_F":77,"temp_K":298.1,"humidity":60,"sources":["viacep"]}`
+	if string(encoded) != expected {
+		t.Errorf("got %s want %s", encoded, expected)
+	}
+
+	var decoded WeatherResponse
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.TempK != 298.1 || decoded.Humidity == nil || *decoded.Humidity != 60 {
+		t.Errorf("unexpected round trip: %+v", decoded)
+	}
+}
+
+func TestWeatherHandler_FixedDecimalsInBody(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?precision=2", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if expected := `{"temp_C":25.00,"temp_F":77.00,"temp_K":298.00`; !strings.HasPrefix(rr.Body.String(), expected) {
+		t.Errorf("got body %s want prefix %s", rr.Body.String(), expected)
+	}
+}
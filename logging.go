@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	logLevelEnv          = "LOG_LEVEL"
+	logUpstreamBodiesEnv = "LOG_UPSTREAM_BODIES"
+
+	// maxLoggedBodyBytes limita o trecho do corpo registrado por LOG_UPSTREAM_BODIES
+	maxLoggedBodyBytes = 2048
+)
+
+// logUpstreamBodies ativa o registro dos corpos das APIs externas (nível debug)
+var logUpstreamBodies = false
+
+// parseLogLevel interpreta o nível de log configurado (debug, info, warn ou error).
+// Vazio equivale a info.
+func parseLogLevel(raw string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", raw)
+}
+
+// newLogger cria o logger JSON da aplicação. Durações são escritas no formato legível
+// do Go (ex: "1.5s") e cada registro recebe os dados da requisição presentes no contexto.
+func newLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Value.Kind() == slog.KindDuration {
+				attr.Value = slog.StringValue(attr.Value.Duration().String())
+			}
+			return attr
+		},
+	})
+	return slog.New(requestContextHandler{handler})
+}
+
+// requestContextHandler acrescenta o ID da requisição a todos os registros e, nos erros,
+// os metadados da requisição (método, path, IP e user agent) para facilitar a triagem
+type requestContextHandler struct {
+	slog.Handler
+}
+
+// Handle enriquece o registro com os dados da requisição antes de repassá-lo
+func (h requestContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(requestLogContextKey{}).(*requestLogContext); ok {
+		record.AddAttrs(slog.String("request_id", info.RequestID))
+		if record.Level >= slog.LevelError && logRequestMetadata {
+			record.AddAttrs(
+				slog.String("method", info.Method),
+				slog.String("path", info.Path),
+				slog.String("client_ip", info.ClientIP),
+				slog.String("user_agent", info.UserAgent),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs preserva o enriquecimento nos loggers derivados
+func (h requestContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup preserva o enriquecimento nos loggers derivados
+func (h requestContextHandler) WithGroup(name string) slog.Handler {
+	return requestContextHandler{h.Handler.WithGroup(name)}
+}
+
+// logUpstreamResponse registra o status e a latência de uma chamada a uma API externa
+func logUpstreamResponse(ctx context.Context, provider string, status int, start time.Time) {
+	slog.DebugContext(ctx, "Upstream request completed", "provider", provider, "status", status, "latency", time.Since(start))
+}
+
+// logUpstreamBody registra o corpo bruto de uma resposta externa, truncado em
+// maxLoggedBodyBytes, quando LOG_UPSTREAM_BODIES está ativo e o nível debug habilitado.
+// O corpo é lido para um buffer e recolocado na resposta, para que a decodificação siga
+// normalmente. A chave da WeatherAPI é mascarada caso o provedor a ecoe no corpo.
+func logUpstreamBody(ctx context.Context, provider string, resp *http.Response) {
+	if !logUpstreamBodies || !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		slog.DebugContext(ctx, "Could not read upstream body for logging", "provider", provider, "error", err)
+		return
+	}
+
+	logged, truncated := body, false
+	if len(logged) > maxLoggedBodyBytes {
+		logged, truncated = logged[:maxLoggedBodyBytes], true
+		// Não corta um caractere UTF-8 ao meio
+		for len(logged) > 0 && !utf8.Valid(logged) {
+			logged = logged[:len(logged)-1]
+		}
+	}
+	text := string(logged)
+	if weatherAPIKey != "" {
+		text = strings.ReplaceAll(text, weatherAPIKey, "[REDACTED]")
+	}
+	slog.DebugContext(ctx, "Upstream response body", "provider", provider, "status", resp.StatusCode, "size", len(body), "truncated", truncated, "body", text)
+}
+
+// fatal registra um erro e encerra o processo, substituindo log.Fatalf
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// captureLogs direciona o logger padrão para um buffer JSON até o fim do teste
+func captureLogs(t *testing.T, level slog.Level) *bytes.Buffer {
+	t.Helper()
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(newLogger(&logs, level))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &logs
+}
+
+// logRecords decodifica as linhas JSON capturadas
+func logRecords(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// findLogRecord retorna o primeiro registro com a mensagem informada
+func findLogRecord(t *testing.T, logs *bytes.Buffer, msg string) map[string]any {
+	t.Helper()
+	for _, record := range logRecords(t, logs) {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	t.Fatalf("log record %q not found in:\n%s", msg, logs.String())
+	return nil
+}
+
+func TestRouter_StructuredLogs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelDebug)
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	served := findLogRecord(t, logs, "Weather request served")
+	expected := map[string]any{"level": "INFO", "cep": "01001000", "city": "São Paulo", "status": float64(http.StatusOK)}
+	for key, value := range expected {
+		if served[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, served[key])
+		}
+	}
+	if latency, ok := served["latency"].(string); !ok || latency == "" {
+		t.Errorf("expected a latency field, got %v", served["latency"])
+	}
+	if served["request_id"] == nil {
+		t.Error("expected a request_id field")
+	}
+
+	upstream := findLogRecord(t, logs, "Upstream request completed")
+	if upstream["level"] != "DEBUG" || upstream["provider"] == nil || upstream["status"] == nil || upstream["latency"] == nil {
+		t.Errorf("unexpected upstream record: %v", upstream)
+	}
+}
+
+func TestNewLogger_Level(t *testing.T) {
+	var logs bytes.Buffer
+	logger := newLogger(&logs, slog.LevelWarn)
+
+	logger.Info("hidden")
+	logger.Warn("visible")
+
+	if strings.Contains(logs.String(), "hidden") || !strings.Contains(logs.String(), "visible") {
+		t.Errorf("expected only records at or above warn, got:\n%s", logs.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{" error ", slog.LevelError},
+	}
+
+	for _, tc := range testCases {
+		level, err := parseLogLevel(tc.raw)
+		if err != nil || level != tc.expected {
+			t.Errorf("parseLogLevel(%q) = %v, %v; want %v", tc.raw, level, err, tc.expected)
+		}
+	}
+
+	if _, err := parseLogLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestWeatherHandler_StrictModeWarnsOnErrorBodyWith200(t *testing.T) {
+	testCases := []struct {
+		name         string
+		strict       bool
+		response     string
+		expectedCode int
+		expectWarn   bool
+	}{
+		{"not found", true, `{"error": {"code": 1006, "message": "No matching location found."}}`, http.StatusNotFound, true},
+		{"other error", true, `{"error": {"code": 9999, "message": "Internal application error."}}`, http.StatusInternalServerError, true},
+		{"strict disabled", false, `{"error": {"code": 1006, "message": "No matching location found."}}`, http.StatusNotFound, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			logs := captureLogs(t, slog.LevelInfo)
+			weatherAPIStrict = tc.strict
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIStatusCode = http.StatusOK
+			mockWeatherAPIResponse = tc.response
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v", rr.Code, tc.expectedCode)
+			}
+
+			warned := false
+			for _, record := range logRecords(t, logs) {
+				if record["msg"] == "WeatherAPI returned an error body with HTTP 200" {
+					warned = record["level"] == "WARN" && record["code"] != nil
+				}
+			}
+			if warned != tc.expectWarn {
+				t.Errorf("expected warning=%t, got %t in:\n%s", tc.expectWarn, warned, logs.String())
+			}
+		})
+	}
+}
+
+// upstreamBodyRecords retorna os registros de corpo de resposta, indexados pelo provedor
+func upstreamBodyRecords(t *testing.T, logs *bytes.Buffer) map[string]map[string]any {
+	t.Helper()
+	records := make(map[string]map[string]any)
+	for _, record := range logRecords(t, logs) {
+		if record["msg"] == "Upstream response body" {
+			records[record["provider"].(string)] = record
+		}
+	}
+	return records
+}
+
+func TestLogUpstreamBodies(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelDebug)
+	logUpstreamBodies = true
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	// Um corpo que ecoa a chave não pode levá-la para o log
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}, "echo": "` + weatherAPIKey + `"}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the body to still decode after logging, got status %d (%s)", rr.Code, rr.Body.String())
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil || response.TempC != 20 {
+		t.Fatalf("unexpected response %+v (error %v)", response, err)
+	}
+
+	records := upstreamBodyRecords(t, logs)
+	viaCEP, weatherAPI := records["viacep"], records["weatherapi"]
+	if viaCEP == nil || weatherAPI == nil {
+		t.Fatalf("expected body records for viacep and weatherapi, got %v", records)
+	}
+	if body := viaCEP["body"].(string); !strings.Contains(body, `"localidade": "São Paulo"`) {
+		t.Errorf("expected the raw ViaCEP body in the log, got %q", body)
+	}
+	if body := weatherAPI["body"].(string); !strings.Contains(body, `"temp_c": 20.0`) || !strings.Contains(body, "[REDACTED]") {
+		t.Errorf("expected the redacted WeatherAPI body in the log, got %q", body)
+	}
+	if strings.Contains(logs.String(), weatherAPIKey) {
+		t.Error("the WeatherAPI key must never be logged")
+	}
+}
+
+func TestLogUpstreamBodies_Truncated(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelDebug)
+	logUpstreamBodies = true
+
+	padding := strings.Repeat("ã", maxLoggedBodyBytes) // 2 bytes por caractere
+	mockViaCEPResponse = `{"localidade": "São Paulo", "complemento": "` + padding + `"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+
+	record := upstreamBodyRecords(t, logs)["viacep"]
+	if record == nil {
+		t.Fatal("expected a body record for viacep")
+	}
+	body := record["body"].(string)
+	if len(body) > maxLoggedBodyBytes || record["truncated"] != true {
+		t.Errorf("expected a truncated body of at most %d bytes, got %d bytes (truncated=%v)", maxLoggedBodyBytes, len(body), record["truncated"])
+	}
+	if !utf8.ValidString(body) {
+		t.Error("expected the truncated body to remain valid UTF-8")
+	}
+}
+
+func TestLogUpstreamBodies_Disabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelDebug)
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	weatherHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if records := upstreamBodyRecords(t, logs); len(records) != 0 {
+		t.Errorf("expected no body records without LOG_UPSTREAM_BODIES, got %v", records)
+	}
+}
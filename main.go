@@ -2,268 +2,1225 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path"
 	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Variáveis globais para clientes HTTP e chave da API
+// Variáveis globais de configuração das APIs externas
 var (
-	httpClient    *http.Client
+	httpUserAgent = defaultHTTPUserAgent // Enviado em todas as chamadas às APIs externas
 	weatherAPIKey string
-	viaCEPURL     string = "https://viacep.com.br"
-	weatherAPIURL string = "https://api.weatherapi.com"
+	viaCEPURL     = defaultViaCEPURL
+	weatherAPIURL = defaultWeatherAPIURL
+
+	// maxFallbackAttempts limita as chamadas externas (incluindo fallbacks) por requisição
+	maxFallbackAttempts = defaultMaxFallbackAttempts
+
+	// batchConcurrency limita quantos CEPs de um lote são resolvidos ao mesmo tempo
+	batchConcurrency = defaultBatchConcurrency
+	// batchMaxSize limita a quantidade de CEPs aceitos em um único lote
+	batchMaxSize = defaultBatchMaxSize
+
+	// totalRequestBudget limita o tempo total de uma requisição, somando todas as chamadas externas
+	totalRequestBudget = defaultTotalRequestBudget
+
+	// weatherAPIStrict registra um aviso sempre que a WeatherAPI responde 200 com um erro no corpo
+	weatherAPIStrict bool
+
+	// debugEndpoints habilita informações de diagnóstico nas respostas (ex: X-Cache-Key)
+	debugEndpoints bool
 )
 
 // ViaCEPResponse Struct para a resposta da API ViaCEP
 type ViaCEPResponse struct {
-	Localidade string `json:"localidade"` // Cidade
-	Erro       bool   `json:"erro"`
+	CEP         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Unidade     string `json:"unidade"`
+	Bairro      string `json:"bairro"`
+	Localidade  string `json:"localidade"` // Cidade
+	UF          string `json:"uf"`
+	Estado      string `json:"estado"`
+	Regiao      string `json:"regiao"`
+	IBGE        string `json:"ibge"`
+	GIA         string `json:"gia"`
+	DDD         string `json:"ddd"`
+	SIAFI       string `json:"siafi"`
+	Erro        bool   `json:"erro"`
+}
+
+// cepLocation reúne os dados de localização resolvidos a partir de um CEP
+type cepLocation struct {
+	City        string
+	UF          string
+	Coordinates *coordinates    // nil quando o CEP não possui coordenadas conhecidas
+	Approximate bool            // A localidade veio do CEP_FALLBACK, não do ViaCEP
+	Address     *ViaCEPResponse // Endereço completo do ViaCEP; nil quando o CEP veio da base local
+	Sources     []string        // Origens consultadas para resolver o CEP (ex: "viacep", "brasilapi")
+
+	// CoordinatesPending indica que as coordenadas da BrasilAPI ainda não foram consultadas,
+	// pois o CEP foi resolvido por uma rota que precisa apenas da cidade (ex: ?only_city=true)
+	CoordinatesPending bool
 }
 
 // WeatherAPIResponse Struct para a resposta da API WeatherAPI (parte relevante)
 type WeatherAPIResponse struct {
 	Current struct {
-		TempC float64 `json:"temp_c"`
+		TempC    float64  `json:"temp_c"`
+		PrecipMM *float64 `json:"precip_mm"` // Ponteiro para distinguir ausência de um 0 real
+		Humidity *int     `json:"humidity"`
+		WindKph  *float64 `json:"wind_kph"`
+
+		FeelsLikeC *float64 `json:"feelslike_c"` // Sensação térmica
+
+		Condition *WeatherAPICondition `json:"condition"` // Descrição textual e ícone do tempo
+
+		LastUpdatedEpoch int64 `json:"last_updated_epoch"` // Momento da medição na estação (Unix, em segundos)
+
+		AirQuality *WeatherAPIAirQuality `json:"air_quality"` // Presente apenas com aqi=yes
 	} `json:"current"`
+	Location struct {
+		Name    string  `json:"name"` // Nome canônico usado pela WeatherAPI (pode diferir do ViaCEP)
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		TzID    string  `json:"tz_id"` // Fuso horário da localização (ex: "America/Sao_Paulo")
+		Lat     float64 `json:"lat"`   // Coordenadas da localização resolvida (usadas pelo modo consenso)
+		Lon     float64 `json:"lon"`
+	} `json:"location"`
 	Error *WeatherAPIError `json:"error,omitempty"` // Ponteiro para detectar ausência de erro
 }
 
+// weatherReading é o resultado de uma consulta de clima, com metadados sobre a sua origem
+type weatherReading struct {
+	*WeatherAPIResponse
+	Stale     bool      // Leitura servida do cache após uma falha da WeatherAPI
+	Degraded  bool      // Leitura servida do cache porque o serviço está em modo degradado
+	FetchedAt time.Time // Momento em que a leitura foi obtida da WeatherAPI
+	Sources   []string  // Origens da leitura (ex: "weatherapi", "weatherapi-cache")
+	CacheKey  string    // Chave da leitura no cache de clima, exposta em X-Cache-Key
+}
+
 // WeatherAPIError Struct para erros da WeatherAPI
 type WeatherAPIError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
 
-// WeatherResponse Struct para a resposta final da nossa API
+// CityResponse Struct para a resposta do modo ?only_city=true
+type CityResponse struct {
+	XMLName xml.Name `json:"-" xml:"location"`
+	City    string   `json:"city" xml:"city"`
+	UF      string   `json:"uf" xml:"uf"`
+}
+
+// UnitResponse Struct para a resposta compacta do modo ?unit=, com uma única escala
+type UnitResponse struct {
+	XMLName xml.Name `json:"-" xml:"weather"`
+	Temp    float64  `json:"temp" xml:"temp"`
+	Unit    string   `json:"unit" xml:"unit"`
+}
+
+// WeatherResponse Struct para a resposta final da nossa API.
+// A ordem dos campos no JSON segue a declaração e faz parte do contrato: as temperaturas
+// vêm primeiro, seguidas pelos campos opcionais. Novos campos devem ser acrescentados ao final
+// (ou use ?canonical=true para chaves em ordem alfabética).
 type WeatherResponse struct {
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	XMLName xml.Name `json:"-" xml:"weather"`
+	TempC   float64  `json:"temp_C" xml:"temp_C"`
+	TempF   float64  `json:"temp_F" xml:"temp_F"`
+	TempK   float64  `json:"temp_K" xml:"temp_K"`
+
+	// Escalas adicionais solicitadas via ?scales=, omitidas na resposta padrão
+	TempR  *float64 `json:"temp_R,omitempty" xml:"temp_R,omitempty"`
+	TempRe *float64 `json:"temp_Re,omitempty" xml:"temp_Re,omitempty"`
+	TempN  *float64 `json:"temp_N,omitempty" xml:"temp_N,omitempty"`
+
+	// Campos do modo estendido (?extended=true), omitidos na resposta padrão
+	PrecipMM      *float64 `json:"precip_mm,omitempty" xml:"precip_mm,omitempty"`
+	Region        string   `json:"region,omitempty" xml:"region,omitempty"`
+	Country       string   `json:"country,omitempty" xml:"country,omitempty"`
+	OutsideBrazil bool     `json:"outside_brazil,omitempty" xml:"outside_brazil,omitempty"` // A WeatherAPI resolveu para outro país
+
+	// Campos selecionados via ?fields=, omitidos na resposta padrão
+	Humidity *int     `json:"humidity,omitempty" xml:"humidity,omitempty"`
+	WindKph  *float64 `json:"wind_kph,omitempty" xml:"wind_kph,omitempty"`
+
+	// Qualidade do ar (?aqi=true), omitida na resposta padrão
+	AirQuality *AirQualityResponse `json:"air_quality,omitempty" xml:"air_quality,omitempty"`
+
+	// Indica que a WeatherAPI falhou e a leitura veio do cache (stale-while-error)
+	Stale bool `json:"stale,omitempty" xml:"stale,omitempty"`
+
+	// Sugestão de quando vale a pena consultar de novo, omitida quando o cache não tem TTL
+	NextUpdateAt *time.Time `json:"next_update_at,omitempty" xml:"next_update_at,omitempty"`
+
+	// Indica que a WeatherAPI está com alta taxa de erros e a leitura veio do cache (modo degradado)
+	Degraded bool `json:"degraded,omitempty" xml:"degraded,omitempty"`
+
+	// Diferença para a referência informada em ?baseline_c=, omitida na resposta padrão
+	DeltaC *float64 `json:"delta_C,omitempty" xml:"delta_C,omitempty"`
+
+	// Nome da cidade como a WeatherAPI a resolveu (?extended=true), para detectar divergências com o ViaCEP
+	ResolvedLocation string `json:"resolved_location,omitempty" xml:"resolved_location,omitempty"`
+
+	// Duração de cada etapa externa (?timing=true), omitida na resposta padrão
+	Timings *TimingsResponse `json:"timings,omitempty" xml:"timings,omitempty"`
+
+	// Sensação térmica selecionada via ?fields=feelslike, omitida na resposta padrão
+	FeelsLike *FeelsLikeResponse `json:"feels_like,omitempty" xml:"feels_like,omitempty"`
+
+	// Indica que o ViaCEP não trouxe a localidade e a leitura é de uma aproximação (CEP_FALLBACK)
+	Approximate bool `json:"approximate,omitempty" xml:"approximate,omitempty"`
+
+	// Leitura de cada provedor no modo ?consensus=true, em que temp_C é a média entre eles
+	Consensus *ConsensusResponse `json:"consensus,omitempty" xml:"consensus,omitempty"`
+
+	// Momento da medição informado pela WeatherAPI, em UTC; ajuda o cliente a avaliar se a leitura está velha
+	ObservedAt *time.Time `json:"observed_at,omitempty" xml:"observed_at,omitempty"`
+
+	// Condição do tempo (texto e ícone) selecionada via ?fields=condition, omitida na resposta padrão
+	Condition *ConditionResponse `json:"condition,omitempty" xml:"condition,omitempty"`
+
+	// Provedores e caches que serviram a resposta (?extended=true), na ordem em que foram usados
+	Sources []string `json:"sources,omitempty" xml:"source,omitempty"`
+
+	// Casas decimais de temp_C, temp_F e temp_K no JSON (ver MarshalJSON); nil usa a menor representação
+	decimals *temperatureDecimals
+}
+
+// FeelsLikeResponse Struct para a sensação térmica, nas mesmas escalas da temperatura
+type FeelsLikeResponse struct {
+	TempC float64 `json:"temp_C" xml:"temp_C"`
+	TempF float64 `json:"temp_F" xml:"temp_F"`
+	TempK float64 `json:"temp_K" xml:"temp_K"`
+}
+
+// newFeelsLikeResponse converte a sensação térmica em Celsius para as demais escalas,
+// com a mesma precisão da temperatura
+func newFeelsLikeResponse(feelsLikeC float64, opts responseOptions) *FeelsLikeResponse {
+	return &FeelsLikeResponse{
+		TempC: opts.roundCelsius(feelsLikeC),
+		TempF: temperatureConverter.Fahrenheit(feelsLikeC, opts.precision()),
+		TempK: temperatureConverter.Kelvin(feelsLikeC, opts.precision()),
+	}
+}
+
+// TimingsResponse Struct com a duração, em milissegundos, de cada etapa externa da requisição.
+// viacep_ms inclui a busca de coordenadas; ambas podem ser 0 quando atendidas pelo cache.
+type TimingsResponse struct {
+	ViaCEPMs     int64 `json:"viacep_ms" xml:"viacep_ms"`
+	WeatherAPIMs int64 `json:"weatherapi_ms" xml:"weatherapi_ms"`
+}
+
+// newTimingsResponse converte as durações medidas para milissegundos
+func newTimingsResponse(viaCEP, weatherAPI time.Duration) *TimingsResponse {
+	return &TimingsResponse{
+		ViaCEPMs:     viaCEP.Milliseconds(),
+		WeatherAPIMs: weatherAPI.Milliseconds(),
+	}
 }
 
 const (
-	viaCEPURLFormat        = "%s/ws/%s/json/"
-	weatherAPIURLFormat    = "%s/v1/current.json?key=%s&q=%s&aqi=no"
-	requestTimeout         = 10 * time.Second
-	defaultPort            = "8080"
-	weatherAPIEnvVar       = "WEATHER_API_KEY"
-	errorInvalidZipcode    = "invalid zipcode"
-	errorCannotFindZip     = "can not find zipcode"
-	errorInternalServer    = "internal server error"
-	errorMissingAPIKey     = "WeatherAPI key not configured"
-	weatherAPINotFoundCode = 1006 // Código específico da WeatherAPI para "No matching location found."
+	viaCEPURLFormat          = "%s/ws/%s/json/"
+	weatherAPIURLFormat      = "%s/v1/current.json?key=%s&q=%s&aqi=%s"
+	requestTimeout           = 10 * time.Second
+	defaultPort              = "8080"
+	weatherAPIEnvVar         = "WEATHER_API_KEY"
+	viaCEPURLEnv             = "VIACEP_URL"
+	weatherAPIURLEnv         = "WEATHER_API_URL"
+	maxFallbackAttemptsEnv   = "MAX_FALLBACK_ATTEMPTS"
+	hostEnv                  = "HOST"
+	portEnv                  = "PORT"
+	shutdownTimeoutEnv       = "SHUTDOWN_TIMEOUT"
+	debugEndpointsEnv        = "DEBUG_ENDPOINTS"
+	httpTimeoutEnv           = "HTTP_TIMEOUT"
+	httpUserAgentEnv         = "HTTP_USER_AGENT"
+	batchConcurrencyEnv      = "BATCH_CONCURRENCY"
+	batchMaxSizeEnv          = "BATCH_MAX_SIZE"
+	totalRequestBudgetEnv    = "TOTAL_REQUEST_BUDGET"
+	weatherAPIStrictEnv      = "WEATHER_API_STRICT"
+	errorInvalidZipcode      = "invalid zipcode"
+	errorMalformedPath       = "malformed path, expected /weather/{cep}"
+	errorMalformedResolve    = "malformed path, expected /resolve/{cep}"
+	errorURITooLong          = "URI too long"
+	errorInvalidPathChars    = "path contains control characters"
+	errorNoCoordinates       = "no coordinates available for Open-Meteo"
+	errorProviderUnavailable = "provider unavailable"
+	errorCEPNotAllowed       = "CEP not served by this deployment"
+	errorCannotFindZip       = "can not find zipcode"
+	errorCannotFindCity      = "can not find city"
+	errorEmptyCityName       = "city name must not be empty"
+	errorNoWeatherStation    = "no weather station near these coordinates"
+	errorInternalServer      = "internal server error"
+	errorMissingAPIKey       = "WeatherAPI key not configured"
+	errorTooManyAttempts     = "too many upstream attempts"
+	errorInvalidFields       = "invalid fields"
+	errorInvalidScales       = "invalid scales"
+	errorInvalidUnit         = "invalid unit"
+	errorUnitNotSupported    = "unit is not supported on the full response"
+	errorInvalidBaseline     = "baseline_c must be a number"
+	errorInvalidPrecision    = "precision must be an integer between 0 and 3"
+	errorInvalidBatchBody    = "request body must be a JSON array of CEPs"
+	errorInvalidForecastDays = "days must be a positive integer"
+	errorRateLimited         = "rate limit exceeded"
+	errorInvalidDate         = "date must be in YYYY-MM-DD format"
+	errorInvalidInterval     = "interval must be an integer between 1 and 24"
+	errorInvalidHourRange    = "from and to must be hours between 0 and 23, with from <= to"
+	errorInvalidTimeout      = "timeout must be a positive duration (e.g. 3s)"
+	errorCircuitOpen         = "weather provider temporarily unavailable"
+	errorUpstreamRateLimited = "weather provider rate limit exceeded, try again later"
+	errorViaCEPRateLimited   = "CEP provider rate limit exceeded, try again later"
+	errorUpstreamBusy        = "too many concurrent upstream requests, try again later"
+	errorRequestTimeout      = "upstream request budget exhausted"
+	errorImpossibleTemp      = "weather provider returned a temperature below absolute zero"
+	errorBadUpstreamResponse = "invalid response from upstream provider"
+	weatherAPINotFoundCode   = 1006 // Código específico da WeatherAPI para "No matching location found."
+
+	defaultViaCEPURL           = "https://viacep.com.br"
+	defaultHTTPUserAgent       = "cep-weather-api/1.0"
+	defaultWeatherAPIURL       = "https://api.weatherapi.com"
+	defaultMaxFallbackAttempts = 8
+	defaultShutdownTimeout     = 15 * time.Second
+	defaultBatchConcurrency    = 5
+	defaultBatchMaxSize        = 50
+	defaultTotalRequestBudget  = 12 * time.Second
 )
 
-// Regex para validar o formato do CEP (8 dígitos numéricos)
-var cepRegex = regexp.MustCompile(`^\d{8}$`)
+// errCannotFindZip indica que o CEP (ou a cidade correspondente) não foi encontrado
+var errCannotFindZip = errors.New(errorCannotFindZip)
+
+// errBadUpstreamResponse indica que uma API externa respondeu com sucesso, mas com um corpo inválido
+var errBadUpstreamResponse = errors.New(errorBadUpstreamResponse)
+
+// errImpossibleTemp indica que a WeatherAPI retornou uma temperatura fisicamente impossível
+var errImpossibleTemp = errors.New(errorImpossibleTemp)
+
+// absoluteZeroCelsius é o zero absoluto em Celsius; leituras abaixo dele vêm de um provedor
+// com defeito e não podem ser convertidas (gerariam Kelvin negativo). Segue o deslocamento
+// usado na conversão para Kelvin, para que toda leitura aceita tenha Kelvin não negativo.
+const absoluteZeroCelsius = -kelvinOffset
+
+// Regex para validar o formato do CEP (8 dígitos numéricos ASCII)
+var cepRegex = regexp.MustCompile(`^[0-9]{8}$`)
+
+// unassignedCEPPrefix é o prefixo das faixas que nunca foram atribuídas (abaixo de 01000-000)
+const unassignedCEPPrefix = "00"
 
 func main() {
-	// Inicializa o cliente HTTP
-	httpClient = &http.Client{
-		Timeout: requestTimeout,
+	// Logs estruturados em JSON; o nível é configurável via LOG_LEVEL
+	logLevel, logLevelErr := parseLogLevel(os.Getenv(logLevelEnv))
+	slog.SetDefault(newLogger(os.Stderr, logLevel))
+	if logLevelErr != nil {
+		slog.Warn("Invalid log level, using info", "env", logLevelEnv, "error", logLevelErr)
+	}
+
+	// Inicializa o cliente HTTP. Um timeout 0 desativaria o limite, então também cai no padrão.
+	httpTimeout := envDuration(httpTimeoutEnv, requestTimeout)
+	if httpTimeout == 0 {
+		httpTimeout = requestTimeout
+	}
+	minTLSVersion := loadMinTLSVersion()
+	defaultClients = newUpstreamClients(&http.Client{
+		Timeout:   httpTimeout,
+		Transport: newUpstreamTransport(minTLSVersion),
+	})
+	viaCEPTimeout = envDuration(viaCEPTimeoutEnv, httpTimeout)
+	weatherAPITimeout = envDuration(weatherAPITimeoutEnv, httpTimeout)
+	httpUserAgent = envString(httpUserAgentEnv, defaultHTTPUserAgent)
+	slog.Info("HTTP client configured", "timeout", httpTimeout, "viacep_timeout", viaCEPTimeout, "weatherapi_timeout", weatherAPITimeout, "min_tls_version", tls.VersionName(minTLSVersion), "user_agent", httpUserAgent)
+
+	// Pega a chave da API do WeatherAPI do arquivo de secret ou das variáveis de ambiente
+	key, err := loadAPIKey()
+	if err != nil {
+		fatal("Could not load WeatherAPI key", "env", weatherAPIKeyFileEnv, "error", err)
+	}
+	if key == "" {
+		fatal("Required environment variable not set", "env", weatherAPIEnvVar)
+	}
+	weatherAPIKey = key
+
+	loadUpstreamURLs()
+	validateAPIKey(context.Background(), envBool(validateAPIKeyOnStartEnv, false))
+	maxFallbackAttempts = envInt(maxFallbackAttemptsEnv, defaultMaxFallbackAttempts)
+	totalRequestBudget = envDuration(totalRequestBudgetEnv, defaultTotalRequestBudget)
+	weatherAPIStrict = envBool(weatherAPIStrictEnv, false)
+	if strategy, ok := parseCEPFallback(os.Getenv(cepFallbackEnv)); ok {
+		cepFallback = strategy
+	} else {
+		slog.Warn("Invalid CEP fallback strategy, disabling fallback", "env", cepFallbackEnv, "value", os.Getenv(cepFallbackEnv))
+	}
+	weatherAPIMaxRetryAfter = envDuration(weatherAPIMaxRetryAfterEnv, defaultWeatherAPIMaxRetryAfter)
+	httpRetryPolicy = loadRetryPolicy()
+	slog.Info("Retry policy configured", "max_retries", httpRetryPolicy.MaxRetries, "base_delay", httpRetryPolicy.BaseDelay, "jitter", httpRetryPolicy.Jitter)
+	responseCacheMaxAge = envDuration(responseCacheMaxAgeEnv, defaultResponseCacheMaxAge)
+	debugEndpoints = envBool(debugEndpointsEnv, false)
+	batchConcurrency = envInt(batchConcurrencyEnv, defaultBatchConcurrency)
+	batchMaxSize = envInt(batchMaxSizeEnv, defaultBatchMaxSize)
+	maxCEPsPerRequest = envInt(maxCEPsPerRequestEnv, defaultMaxCEPsPerRequest)
+	validateMaxSize = envInt(validateMaxSizeEnv, defaultValidateMaxSize)
+	forecastMaxDays = min(envInt(forecastMaxDaysEnv, defaultForecastMaxDays), maxForecastDays)
+	staleGracePeriod = envDuration(staleGracePeriodEnv, defaultStaleGracePeriod)
+	cepCacheTTL = envDuration(cepCacheTTLEnv, defaultCEPCacheTTL)
+	weatherCacheTTL = envDuration(weatherCacheTTLEnv, defaultWeatherCacheTTL)
+	cacheDisabled = envBool(disableCacheEnv, false)
+	logRequestMetadata = envBool(logRequestMetadataEnv, true)
+	logUpstreamBodies = envBool(logUpstreamBodiesEnv, false)
+	maxPathLength = envInt(maxPathLengthEnv, defaultMaxPathLength)
+	basePath = normalizeBasePath(os.Getenv(basePathEnv))
+	if basePath != "" {
+		slog.Info("Serving routes under base path", "base_path", basePath)
+	}
+	if allowedCEPPrefixes, err = parseCEPPrefixes(os.Getenv(allowedCEPPrefixesEnv)); err != nil {
+		fatal("Invalid CEP prefixes", "env", allowedCEPPrefixesEnv, "error", err)
+	}
+	if deniedCEPPrefixes, err = parseCEPPrefixes(os.Getenv(deniedCEPPrefixesEnv)); err != nil {
+		fatal("Invalid CEP prefixes", "env", deniedCEPPrefixesEnv, "error", err)
+	}
+	if len(allowedCEPPrefixes) > 0 || len(deniedCEPPrefixes) > 0 {
+		slog.Info("CEP prefix filter configured", "allowed", allowedCEPPrefixes, "denied", deniedCEPPrefixes)
+	}
+	if logUpstreamBodies && logLevel > slog.LevelDebug {
+		slog.Warn("Upstream body logging requires debug level", "env", logUpstreamBodiesEnv, "log_level_env", logLevelEnv)
+	}
+	accessLogEnabled = envBool(accessLogEnv, false)
+	forwardedSkipPrivate = envBool(forwardedSkipPrivateEnv, true)
+	if raw := os.Getenv(trustedProxiesEnv); raw != "" {
+		networks, err := parseTrustedProxies(raw)
+		if err != nil {
+			fatal("Invalid trusted proxies", "env", trustedProxiesEnv, "error", err)
+		}
+		trustedProxies = networks
+		slog.Info("Trusted proxies configured", "networks", networks)
+	}
+	gzipMinSize = envInt(gzipMinSizeEnv, defaultGzipMinSize)
+	responseHMACSecret = []byte(os.Getenv(responseHMACSecretEnv))
+
+	// Base de CEPs offline, consultada antes dos provedores de rede
+	if path := os.Getenv(cepDBPathEnv); path != "" {
+		db, err := loadCEPDatabase(path)
+		if err != nil {
+			fatal("Failed to load CEP database", "path", path, "error", err)
+		}
+		localCEPDB = db
+		cepDBFallthrough = envBool(cepDBFallthroughEnv, true)
+		slog.Info("Loaded local CEP database", "path", path, "ceps", len(db.entries), "network_fallthrough", cepDBFallthrough)
+	}
+
+	// O rate limit por IP só é ativado quando RATE_LIMIT_RPS é configurado
+	if rps := envFloat(rateLimitRPSEnv, 0); rps > 0 {
+		clientRateLimiter = newRateLimiter(rps, envInt(rateLimitBurstEnv, 0))
+		slog.Info("Rate limiting enabled", "rps", rps)
+	}
+	if raw := os.Getenv(rateLimitRoutesEnv); raw != "" {
+		limiters, err := parseRouteRateLimits(raw)
+		if err != nil {
+			fatal("Invalid route rate limits", "env", rateLimitRoutesEnv, "error", err)
+		}
+		routeRateLimiters = limiters
+		for route, limiter := range limiters {
+			slog.Info("Route rate limiting enabled", "route", route, "rps", limiter.rps)
+		}
 	}
 
-	// Pega a chave da API do WeatherAPI das variáveis de ambiente
-	weatherAPIKey = os.Getenv(weatherAPIEnvVar)
-	if weatherAPIKey == "" {
-		log.Fatalf("%s environment variable not set", weatherAPIEnvVar)
+	// Circuit breaker da WeatherAPI; um limite <= 0 desativa
+	if threshold := envInt(circuitBreakerThresholdEnv, defaultCircuitBreakerThreshold); threshold > 0 {
+		cooldown := envDuration(circuitBreakerCooldownEnv, defaultCircuitBreakerCooldown)
+		weatherBreaker = newCircuitBreaker("weatherapi", threshold, cooldown)
+		slog.Info("Circuit breaker enabled", "breaker", weatherBreaker.name, "threshold", threshold, "cooldown", cooldown)
+	}
+
+	// Modo degradado: serve o cache quando a taxa de erros da WeatherAPI passa do limite; <= 0 desativa
+	if rate := envFloat(degradedErrorRateEnv, 0); rate > 0 {
+		window := envDuration(degradedWindowEnv, defaultDegradedWindow)
+		minRequests := envInt(degradedMinRequestsEnv, defaultDegradedMinRequests)
+		weatherErrorRate = newErrorRateTracker(rate, window, minRequests)
+		slog.Info("Degraded mode enabled", "error_rate", rate, "window", window, "min_requests", minRequests)
+	}
+
+	// Limite de chamadas externas simultâneas; <= 0 desativa
+	if size := envInt(maxConcurrentUpstreamEnv, 0); size > 0 {
+		upstreamSlots = newUpstreamLimiter(size)
+		slog.Info("Upstream concurrency limit enabled", "max_concurrent", size)
+	}
+
+	// Aquecimento opcional do cache de CEPs, antes de aceitar tráfego
+	if ceps := parseWarmupCEPs(os.Getenv(warmupCEPsEnv)); len(ceps) > 0 {
+		if cacheDisabled || cepCacheTTL <= 0 {
+			slog.Warn("Cache warmup skipped, CEP cache is disabled", "env", warmupCEPsEnv)
+		} else {
+			warmupCEPCache(context.Background(), ceps, batchConcurrency, envDuration(warmupTimeoutEnv, defaultWarmupTimeout))
+		}
 	}
 
-	// Define o handler da rota principal
-	http.HandleFunc("/weather/", weatherHandler) // Usar /weather/ para capturar o CEP na URL
+	shutdownTimeout := envDuration(shutdownTimeoutEnv, defaultShutdownTimeout)
 
-	// Define a porta que a aplicação vai escutar
-	port := os.Getenv("PORT")
+	// Define o endereço que a aplicação vai escutar; sem HOST, todas as interfaces
+	port := os.Getenv(portEnv)
 	if port == "" {
 		port = defaultPort
 	}
 
-	log.Printf("Server starting on port %s\n", port)
+	listener, err := net.Listen("tcp", listenAddress(os.Getenv(hostEnv), port))
+	if err != nil {
+		fatal("Failed to start server", "error", err)
+	}
+	server := &http.Server{Handler: newRouter()}
+
+	// Cancela o contexto ao receber SIGTERM (deploy) ou SIGINT (Ctrl+C)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// Watchdog opcional contra travamentos (ex: deadlock no cache ou no pool)
+	if timeout := envDuration(watchdogTimeoutEnv, 0); timeout > 0 {
+		wd := newWatchdog(timeout, envBool(watchdogExitEnv, false))
+		go runHeartbeat(ctx, wd, probeServingPath)
+		go wd.run(ctx)
+		slog.Info("Watchdog enabled", "timeout", timeout, "exit_on_stall", wd.exitOnStall)
+	}
+
+	// Tracing opcional: sem OTEL_EXPORTER_OTLP_ENDPOINT os spans não são exportados
+	shutdownTracing, err := initTracing(ctx)
+	if err != nil {
+		fatal("Failed to initialize tracing", "error", err)
+	}
+
+	slog.Info("Server starting", "address", listener.Addr().String())
 	// Inicia o servidor HTTP
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if err := runServer(ctx, server, listener, shutdownTimeout); err != nil {
+		fatal("Server error", "error", err)
+	}
+
+	// Envia os spans pendentes antes de sair
+	tracingCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
 	}
 }
 
-// weatherHandler é o handler principal para a rota /weather/{cep}
+// loadUpstreamURLs permite apontar as APIs externas para mocks ou gateways alternativos
+// sem recompilar, mantendo os endereços oficiais como padrão
+func loadUpstreamURLs() {
+	viaCEPURL = strings.TrimSuffix(envString(viaCEPURLEnv, defaultViaCEPURL), "/")
+	weatherAPIURL = strings.TrimSuffix(envString(weatherAPIURLEnv, defaultWeatherAPIURL), "/")
+	openMeteoURL = strings.TrimSuffix(envString(openMeteoURLEnv, defaultOpenMeteoURL), "/")
+	slog.Info("Upstream URLs configured", "viacep", viaCEPURL, "weatherapi", weatherAPIURL, "openmeteo", openMeteoURL)
+}
+
+// weatherHandler é o handler principal das rotas sob /weather/. HEAD executa as mesmas
+// validações e consultas do GET, retornando o mesmo status, mas sem corpo. A rota é
+// escolhida pelos padrões de weatherRoutes, que também respondem 405 com o cabeçalho Allow.
 func weatherHandler(w http.ResponseWriter, r *http.Request) {
-	// Extrai o CEP da URL path
-	// Ex: /weather/12345678 -> parts = ["", "weather", "12345678"]
-	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
-	if len(parts) != 2 || parts[0] != "weather" {
-		http.Error(w, "Usage: /weather/{cep}", http.StatusNotFound) // Ou Bad Request
+	// O span da requisição continua o trace recebido e termina com o status enviado
+	spanCtx, span := startServerSpan(r, "weatherHandler")
+	r = r.WithContext(spanCtx)
+	recorder := &statusRecorder{ResponseWriter: w}
+	w = recorder
+	defer func() { endServerSpan(span, recorder.statusCode()) }()
+
+	if r.Method == http.MethodHead {
+		w = headResponseWriter{w}
+	}
+
+	// O ServeMux redirecionaria caminhos não canônicos (ex: /weather/01001000//) com 301;
+	// eles são um erro de rota, como os segmentos a mais. Uma barra final é tolerada.
+	if path.Clean(r.URL.Path) != strings.TrimSuffix(r.URL.Path, "/") {
+		writeError(w, r, http.StatusBadRequest, errorMalformedPath) // 400
 		return
 	}
-	cep := parts[1]
+	weatherRoutes.ServeHTTP(w, r)
+}
+
+// malformedPathHandler responde às rotas sob /weather/ que não correspondem a nenhum padrão
+func malformedPathHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusBadRequest, errorMalformedPath) // 400
+}
+
+// currentWeatherHandler atende GET /weather/{cep}, com as condições atuais da cidade do CEP
+func currentWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	cep := r.PathValue("cep")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("cep", cep))
+
+	// Vários CEPs separados por vírgula: um resultado por CEP, como no lote
+	if strings.Contains(cep, ",") {
+		multiCEPHandler(w, r, cep)
+		return
+	}
+
+	result, ok := resolveCEPWeather(w, r, cep)
+	if !ok {
+		return
+	}
+
+	// Modo leve: responde apenas com a cidade, sem consultar a WeatherAPI
+	if result.Weather == nil {
+		writeResponse(w, r, http.StatusOK, CityResponse{City: result.Location.City, UF: result.Location.UF})
+		return
+	}
+
+	// 6. Envia a resposta (JSON por padrão, ou XML/texto quando solicitado)
+	writeWeatherResponse(w, r, result.Location.City, *result.Weather, result.Options) // 200
+}
+
+// cepWeatherResult é o resultado de resolveCEPWeather. Weather é nil no modo ?only_city=true.
+type cepWeatherResult struct {
+	Location cepLocation
+	Weather  *WeatherResponse
+	Options  responseOptions
+}
+
+// resolveCEPWeather executa o fluxo comum das rotas de clima por CEP (/weather/{cep} e
+// /weather/{cep}/full): valida o CEP e as opções, resolve a cidade e monta a resposta de
+// clima, deixando ao handler apenas o formato do corpo. Em caso de erro, a resposta já foi
+// enviada e o retorno é false.
+func resolveCEPWeather(w http.ResponseWriter, r *http.Request, cep string) (cepWeatherResult, bool) {
+	start := time.Now()
+	span := trace.SpanFromContext(r.Context())
 
 	// 1. Valida o formato do CEP
 	if !isValidCEP(cep) {
-		http.Error(w, errorInvalidZipcode, http.StatusUnprocessableEntity) // 422
-		return
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidZipcode) // 422
+		return cepWeatherResult{}, false
 	}
 
-	// 2. Busca a cidade usando o ViaCEP
-	cityName, err := getCityFromCEP(r.Context(), cep)
+	opts, err := parseResponseOptions(r)
 	if err != nil {
-		// Verifica se o erro é "não encontrado" ou outro erro
-		if err.Error() == errorCannotFindZip {
-			http.Error(w, errorCannotFindZip, http.StatusNotFound) // 404
-		} else {
-			log.Printf("Error getting city from CEP %s: %v", cep, err)
-			http.Error(w, errorInternalServer, http.StatusInternalServerError) // 500
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error()) // 422
+		return cepWeatherResult{}, false
+	}
+
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	// 2. Busca a cidade usando o ViaCEP (ou o cache de CEPs)
+	viaCEPStart := time.Now()
+	location, cacheHit, err := lookupCEP(ctx, defaultClients, cep, !opts.OnlyCity)
+	viaCEPDuration := time.Since(viaCEPStart)
+	w.Header().Set(cacheHeader, cacheStatus(cacheHit))
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting city from CEP", "cep", cep, "status", status, "error", err)
 		}
-		return
+		writeError(w, r, status, message)
+		return cepWeatherResult{}, false
 	}
+	cityName := location.City
+	span.SetAttributes(attribute.String("city", cityName))
 
-	// 3. Busca a temperatura usando a WeatherAPI
-	tempC, err := getWeatherForCity(r.Context(), cityName)
+	// Modo leve: apenas a cidade, sem consultar a WeatherAPI
+	if opts.OnlyCity {
+		return cepWeatherResult{Location: location, Options: opts}, true
+	}
+
+	// 3. Busca a temperatura usando a WeatherAPI (e a Open-Meteo no modo consenso)
+	weatherAPIStart := time.Now()
+	weather, consensus, err := getWeather(ctx, defaultClients, location, opts)
+	weatherAPIDuration := time.Since(weatherAPIStart)
 	if err != nil {
-		// Verifica se o erro é "não encontrado" ou outro erro
-		if err.Error() == errorCannotFindZip {
-			// Mapeia o erro de cidade não encontrada na WeatherAPI para o erro 404 do requisito
-			http.Error(w, errorCannotFindZip, http.StatusNotFound) // 404
-		} else {
-			log.Printf("Error getting weather for city %s (from CEP %s): %v", cityName, cep, err)
-			http.Error(w, errorInternalServer, http.StatusInternalServerError) // 500
+		// Cidade não encontrada na WeatherAPI é mapeada para o erro 404 do requisito
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting weather for city", "cep", cep, "city", cityName, "status", status, "error", err)
 		}
-		return
+		writeError(w, r, status, message)
+		return cepWeatherResult{}, false
 	}
 
-	// 4. Calcula as temperaturas em F e K
-	tempF := celsiusToFahrenheit(tempC)
-	tempK := celsiusToKelvin(tempC)
+	// 4 e 5. Calcula as temperaturas em F e K e prepara a resposta de sucesso
+	response := newWeatherResponse(weather, opts)
+	response.Approximate = location.Approximate
+	response.Consensus = consensus
+	if opts.Timing {
+		response.Timings = newTimingsResponse(viaCEPDuration, weatherAPIDuration)
+	}
+	if opts.Extended {
+		response.Sources = responseSources(location, weather)
+	}
+	slog.InfoContext(ctx, "Weather request served", "cep", cep, "city", cityName, "status", http.StatusOK, "stale", weather.Stale, "degraded", weather.Degraded, "latency", time.Since(start))
+	setDegradedHeader(w, weather)
+	setCacheKeyHeader(w, weather)
+	return cepWeatherResult{Location: location, Weather: &response, Options: opts}, true
+}
 
-	// 5. Prepara a resposta de sucesso
+// getWeather busca a leitura de clima da localização, combinando os provedores quando
+// ?consensus=true foi pedido
+func getWeather(ctx context.Context, clients upstreamClients, location cepLocation, opts responseOptions) (*weatherReading, *ConsensusResponse, error) {
+	if opts.Consensus {
+		return getConsensusWeather(ctx, clients, location, opts.AirQuality)
+	}
+	weather, err := getWeatherForCity(ctx, clients, location, opts.AirQuality)
+	return weather, nil, err
+}
+
+// writeWeatherResponse envia a leitura no formato pedido: texto puro (com o nome da cidade),
+// apenas a escala de ?unit= ou a resposta completa em JSON/XML. O corpo leva um ETag, e
+// clientes que já têm a mesma versão recebem 304.
+func writeWeatherResponse(w http.ResponseWriter, r *http.Request, city string, response WeatherResponse, opts responseOptions) {
+	writeWithETag(w, r, func(w http.ResponseWriter) {
+		// Texto puro para uso no terminal (ex: curl)
+		if responseFormat(r) == formatText {
+			writeText(w, http.StatusOK, weatherText(city, response))
+			return
+		}
+
+		// Modo compacto: apenas a escala preferida pelo cliente
+		if opts.Unit != "" {
+			writeResponse(w, r, http.StatusOK, newUnitResponse(response, opts.Unit))
+			return
+		}
+
+		writeResponse(w, r, http.StatusOK, response)
+	})
+}
+
+// upstreamContext prepara o contexto das chamadas externas de uma requisição: todas
+// compartilham o mesmo limite de tentativas e o mesmo prazo, de modo que um ViaCEP lento
+// deixa menos tempo para a WeatherAPI
+func upstreamContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx := withAttemptBudget(r.Context(), maxFallbackAttempts)
+	if totalRequestBudget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, totalRequestBudget)
+}
+
+// newUnitResponse extrai da resposta completa a temperatura na escala solicitada,
+// preservando os arredondamentos já aplicados (ex: ?whole_kelvin=true)
+func newUnitResponse(response WeatherResponse, unit string) UnitResponse {
+	switch unit {
+	case unitFahrenheit:
+		return UnitResponse{Temp: response.TempF, Unit: unit}
+	case unitKelvin:
+		return UnitResponse{Temp: response.TempK, Unit: unit}
+	default:
+		return UnitResponse{Temp: response.TempC, Unit: unitCelsius}
+	}
+}
+
+// newWeatherResponse converte a resposta da WeatherAPI na resposta da nossa API,
+// incluindo os campos opcionais solicitados
+func newWeatherResponse(weather *weatherReading, opts responseOptions) WeatherResponse {
+	tempC := weather.Current.TempC
+	precision := opts.precision()
 	response := WeatherResponse{
-		TempC: tempC,
-		TempF: tempF,
-		TempK: tempK,
+		TempC:    opts.roundCelsius(tempC),
+		TempF:    temperatureConverter.Fahrenheit(tempC, precision),
+		TempK:    temperatureConverter.Kelvin(tempC, precision),
+		Stale:    weather.Stale,
+		Degraded: weather.Degraded,
+		decimals: newTemperatureDecimals(opts),
+	}
+
+	if nextUpdate, ok := nextUpdateAt(weather); ok {
+		response.NextUpdateAt = &nextUpdate
+	}
+	if epoch := weather.Current.LastUpdatedEpoch; epoch > 0 {
+		observedAt := time.Unix(epoch, 0).UTC()
+		response.ObservedAt = &observedAt
+	}
+
+	if opts.Scales[scaleRankine] {
+		tempR := temperatureConverter.Rankine(tempC, precision)
+		response.TempR = &tempR
+	}
+	if opts.Scales[scaleReaumur] {
+		tempRe := temperatureConverter.Reaumur(tempC, precision)
+		response.TempRe = &tempRe
+	}
+	if opts.Scales[scaleNewton] {
+		tempN := temperatureConverter.Newton(tempC, precision)
+		response.TempN = &tempN
+	}
+
+	// A diferença usa o Celsius original da WeatherAPI e é arredondada uma única vez
+	if opts.BaselineC != nil {
+		deltaC := roundFloat(tempC-*opts.BaselineC, precision)
+		response.DeltaC = &deltaC
+	}
+
+	// Kelvin inteiro é calculado a partir do Celsius original, evitando arredondar duas vezes
+	if opts.WholeKelvin {
+		response.TempK = temperatureConverter.Kelvin(tempC, 0)
+	}
+
+	if opts.Extended {
+		response.PrecipMM = weather.Current.PrecipMM
+		response.ResolvedLocation = weather.Location.Name
+		response.Region = weather.Location.Region
+		response.Country = weather.Location.Country
+		response.OutsideBrazil = isOutsideBrazil(weather.Location.Country)
+	}
+	if opts.Fields[fieldHumidity] {
+		response.Humidity = weather.Current.Humidity
+	}
+	if opts.Fields[fieldWind] {
+		response.WindKph = weather.Current.WindKph
+	}
+	if opts.Fields[fieldFeelsLike] && weather.Current.FeelsLikeC != nil {
+		response.FeelsLike = newFeelsLikeResponse(*weather.Current.FeelsLikeC, opts)
+	}
+	if opts.Fields[fieldCondition] {
+		response.Condition = newConditionResponse(weather.Current.Condition)
+	}
+	if opts.AirQuality {
+		response.AirQuality = newAirQualityResponse(weather.Current.AirQuality)
+	}
+	return response
+}
+
+// isOutsideBrazil indica se o país retornado pela WeatherAPI não é o Brasil, sinal de que
+// a cidade foi confundida com uma homônima no exterior. País vazio não é considerado suspeito.
+func isOutsideBrazil(country string) bool {
+	if country == "" {
+		return false
 	}
+	return !strings.EqualFold(country, "Brazil") && !strings.EqualFold(country, "Brasil")
+}
+
+// writeJSON envia uma resposta JSON com o status informado
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, body any) {
+	setCacheControl(w, status)
+	encodeJSON(ctx, w, status, body)
+}
 
-	// 6. Envia a resposta JSON
+// encodeJSON escreve o status e o corpo JSON, com os demais cabeçalhos já definidos
+func encodeJSON(ctx context.Context, w http.ResponseWriter, status int, body any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // 200
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
 		// Loga o erro, mas não tenta escrever mais na resposta, pois o header já foi enviado
-		log.Printf("Error encoding success response for CEP %s: %v", cep, err)
+		slog.ErrorContext(ctx, "Error encoding response", "error", err)
 	}
 }
 
-// isValidCEP verifica se a ‘string’ do CEP tem 8 dígitos numéricos
+// upstreamErrorStatus mapeia um erro das APIs externas para o status HTTP e a mensagem da nossa API
+func upstreamErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errCEPNotAllowed):
+		return http.StatusForbidden, errorCEPNotAllowed // 403
+	case errors.Is(err, errCannotFindZip):
+		return http.StatusNotFound, errorCannotFindZip // 404
+	case errors.Is(err, errNoWeatherStation):
+		return http.StatusNotFound, errorNoWeatherStation // 404
+	case errors.Is(err, errTooManyAttempts):
+		return http.StatusBadGateway, errorTooManyAttempts // 502
+	case errors.Is(err, errBadUpstreamResponse):
+		return http.StatusBadGateway, errorBadUpstreamResponse // 502
+	case errors.Is(err, errCircuitOpen):
+		return http.StatusServiceUnavailable, errorCircuitOpen // 503
+	case errors.Is(err, errUpstreamRateLimited):
+		return http.StatusServiceUnavailable, errorUpstreamRateLimited // 503
+	case errors.Is(err, errViaCEPRateLimited):
+		return http.StatusServiceUnavailable, errorViaCEPRateLimited // 503
+	case errors.Is(err, errUpstreamBusy):
+		return http.StatusServiceUnavailable, errorUpstreamBusy // 503
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, errorRequestTimeout // 504
+	default:
+		return http.StatusInternalServerError, errorInternalServer // 500
+	}
+}
+
+// isValidCEP verifica se a ‘string’ do CEP tem 8 dígitos numéricos e pertence a uma faixa
+// atribuída pelos Correios, evitando consultar o ViaCEP para CEPs claramente inexistentes
 func isValidCEP(cep string) bool {
-	return cepRegex.MatchString(cep)
+	return !hasNonASCIIDigit(cep) && cepRegex.MatchString(cep) && !isUnassignedCEP(cep)
 }
 
-// getCityFromCEP busca a cidade correspondente a um CEP usando a API ViaCEP
-func getCityFromCEP(ctx context.Context, cep string) (string, error) {
+// hasNonASCIIDigit detecta dígitos Unicode fora do ASCII (ex: os de largura total "０１００１０００",
+// que alguns proxies deixam passar). Eles são rejeitados, e não convertidos, para que nunca
+// cheguem à montagem das URLs das APIs externas.
+func hasNonASCIIDigit(cep string) bool {
+	for _, r := range cep {
+		if r > unicode.MaxASCII && unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnassignedCEP detecta CEPs bem formados que não podem existir. A numeração dos Correios
+// começa em 01000-000, então qualquer CEP iniciado por "00" (incluindo 00000000) é rejeitado.
+// Sequências como 11111111 ou 99999999 caem em faixas atribuídas e seguem para a consulta.
+func isUnassignedCEP(cep string) bool {
+	return strings.HasPrefix(cep, unassignedCEPPrefix)
+}
+
+// normalizeCEP remove espaços e a pontuação usual de um CEP (ex: "01001-000" ou "01.001-000" -> "01001000")
+func normalizeCEP(cep string) string {
+	return strings.NewReplacer("-", "", ".", "", " ", "").Replace(strings.TrimSpace(cep))
+}
+
+// getCityFromCEP busca a cidade (e a UF) correspondente a um CEP, consultando primeiro a
+// base offline (se configurada) e depois a API ViaCEP. As coordenadas da BrasilAPI só são
+// buscadas com withCoordinates, já que apenas a consulta de clima as usa.
+func getCityFromCEP(ctx context.Context, clients upstreamClients, cep string, withCoordinates bool) (location cepLocation, err error) {
+	ctx, span := startSpan(ctx, "getCityFromCEP", attribute.String("cep", cep))
+	defer func() {
+		span.SetAttributes(attribute.String("city", location.City))
+		endSpan(span, err)
+	}()
+
+	if localCEPDB != nil {
+		if location, ok := localCEPDB.lookup(cep); ok {
+			slog.InfoContext(ctx, "CEP resolved to city from local database", "cep", cep, "city", location.City)
+			location.Sources = []string{sourceCEPDatabase}
+			return location, nil
+		}
+		if !cepDBFallthrough {
+			return cepLocation{}, errCannotFindZip
+		}
+	}
+
+	viaCEPResp, err := callViaCEP(ctx, clients.ViaCEP, cep)
+	if err != nil {
+		return cepLocation{}, err
+	}
+
+	// ViaCEP retorna {"erro": true} para CEPs não encontrados
+	if viaCEPResp.Erro {
+		return cepLocation{}, errCannotFindZip
+	}
+	// CEP existente, mas sem localidade: a estratégia de CEP_FALLBACK pode aproximá-la
+	city := normalizeCityName(viaCEPResp.Localidade)
+	if city == "" {
+		if location, ok := fallbackLocation(ctx, clients, cep, viaCEPResp.UF); ok {
+			location.Address = &viaCEPResp
+			location.Sources = append([]string{sourceViaCEP}, location.Sources...)
+			return location, nil
+		}
+		return cepLocation{}, errCannotFindZip
+	}
+
+	slog.InfoContext(ctx, "CEP resolved to city", "cep", cep, "city", city, "uf", viaCEPResp.UF)
+	location = cepLocation{City: city, UF: strings.TrimSpace(viaCEPResp.UF), Address: &viaCEPResp, Sources: []string{sourceViaCEP}, CoordinatesPending: true}
+	if withCoordinates {
+		resolveCoordinates(ctx, clients, cep, &location)
+	}
+	return location, nil
+}
+
+// resolveCoordinates completa a localização com as coordenadas da BrasilAPI. Elas são
+// opcionais: sem elas a WeatherAPI é consultada pelo nome da cidade.
+func resolveCoordinates(ctx context.Context, clients upstreamClients, cep string, location *cepLocation) {
+	location.CoordinatesPending = false
+	coords, err := getCoordinatesFromCEP(ctx, clients.BrasilAPI, cep)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not get coordinates for CEP, falling back to city name", "cep", cep, "error", err)
+		return
+	}
+	if coords != nil {
+		slog.InfoContext(ctx, "CEP resolved to coordinates", "cep", cep, "coordinates", coords.String())
+		location.Coordinates = coords
+		// Clip evita que o append altere as origens compartilhadas pelo cache de CEPs
+		location.Sources = append(slices.Clip(location.Sources), sourceBrasilAPI)
+	}
+}
+
+// callViaCEPOnce executa uma única chamada ao ViaCEP, com o prazo próprio de VIACEP_TIMEOUT.
+// Um 429 vira viaCEPRateLimitedError, que callViaCEP pode repetir.
+func callViaCEPOnce(ctx context.Context, client *http.Client, cep string) (ViaCEPResponse, error) {
+	if err := consumeAttempt(ctx); err != nil {
+		return ViaCEPResponse{}, err
+	}
+
+	// O prazo próprio vale só para a chamada ao ViaCEP; o fallback usa o contexto da requisição
+	callCtx, cancel := withUpstreamTimeout(ctx, viaCEPTimeout)
+	defer cancel()
+
 	cepURL := fmt.Sprintf(viaCEPURLFormat, viaCEPURL, cep)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cepURL, nil)
+	req, err := newUpstreamRequest(callCtx, cepURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to create ViaCEP request: %w", err)
+		return ViaCEPResponse{}, fmt.Errorf("failed to create ViaCEP request: %w", err)
 	}
 
-	resp, err := httpClient.Do(req)
+	start := time.Now()
+	resp, err := doUpstreamRequest(client, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute ViaCEP request: %w", err)
+		return ViaCEPResponse{}, fmt.Errorf("failed to execute ViaCEP request: %w", err)
 	}
 	defer resp.Body.Close()
+	logUpstreamResponse(ctx, "viacep", resp.StatusCode, start)
+	logUpstreamBody(ctx, "viacep", resp)
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return ViaCEPResponse{}, newViaCEPRateLimitedError(resp)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ViaCEP request failed with status: %s", resp.Status)
+		return ViaCEPResponse{}, fmt.Errorf("ViaCEP request failed with status: %s", resp.Status)
 	}
 
+	// Um 200 com corpo vazio ou que não é JSON indica falha do provedor, não um CEP inexistente
 	var viaCEPResp ViaCEPResponse
 	if err := json.NewDecoder(resp.Body).Decode(&viaCEPResp); err != nil {
-		return "", fmt.Errorf(errorCannotFindZip)
+		slog.WarnContext(ctx, "ViaCEP returned an invalid body", "cep", cep, "error", err)
+		return ViaCEPResponse{}, fmt.Errorf("%w: ViaCEP body could not be decoded: %v", errBadUpstreamResponse, err)
 	}
+	return viaCEPResp, nil
+}
 
-	// ViaCEP retorna {"erro": true} para CEPs não encontrados
-	if viaCEPResp.Erro || viaCEPResp.Localidade == "" {
-		return "", fmt.Errorf(errorCannotFindZip)
+// getWeatherForCity busca as condições atuais para uma cidade usando a WeatherAPI.
+// Quando as coordenadas são conhecidas, consulta por "lat,lon", evitando a ambiguidade de
+// cidades homônimas em estados diferentes; caso contrário, consulta pelo nome da cidade e UF.
+// Se a WeatherAPI falhar, serve a última leitura em cache dentro da janela de tolerância.
+func getWeatherForCity(ctx context.Context, clients upstreamClients, location cepLocation, includeAirQuality bool) (reading *weatherReading, err error) {
+	ctx, span := startSpan(ctx, "getWeatherForCity", attribute.String("city", location.City), attribute.String("uf", location.UF))
+	defer func() { endSpan(span, err) }()
+
+	query := weatherQuery(location)
+	key := readingCacheKey(query, includeAirQuality)
+
+	// Leituras dentro do TTL são servidas sem consultar a WeatherAPI, poupando a cota
+	if !cacheDisabled && weatherCacheTTL > 0 {
+		if cached, age, ok := weatherCache.get(key); ok && age <= weatherCacheTTL {
+			slog.DebugContext(ctx, "Weather served from cache", "query", query, "age", age.Round(time.Second))
+			return &weatherReading{WeatherAPIResponse: cached, FetchedAt: weatherCache.now().Add(-age), Sources: cachedSources([]string{sourceWeatherAPI}), CacheKey: key}, nil
+		}
+	}
+
+	// Modo degradado: com a WeatherAPI falhando com frequência, qualquer leitura em cache é
+	// servida sem nova consulta, poupando o provedor e a latência do cliente
+	degraded := weatherErrorRate.degraded()
+	if degraded && !cacheDisabled {
+		if cached, age, ok := weatherCache.get(key); ok {
+			slog.WarnContext(ctx, "Degraded mode, serving cached reading", "query", query, "age", age.Round(time.Second))
+			return &weatherReading{WeatherAPIResponse: cached, Stale: age > weatherCacheTTL, Degraded: true, FetchedAt: weatherCache.now().Add(-age), Sources: cachedSources([]string{sourceWeatherAPI}), CacheKey: key}, nil
+		}
 	}
 
-	log.Printf("CEP %s resolved to city: %s", cep, viaCEPResp.Localidade)
-	return viaCEPResp.Localidade, nil
+	// Com o circuito aberto a WeatherAPI não é consultada, mas o cache ainda pode responder
+	var weather *WeatherAPIResponse
+	if weatherBreaker.allow() {
+		weather, err = fetchWeather(ctx, clients.WeatherAPI, query, includeAirQuality)
+		outcome := breakerOutcome(ctx, err)
+		weatherBreaker.record(outcome)
+		weatherErrorRate.record(outcome)
+	} else {
+		err = errCircuitOpen
+	}
+	if err == nil {
+		if !cacheDisabled {
+			weatherCache.set(key, weather)
+		}
+		return &weatherReading{WeatherAPIResponse: weather, FetchedAt: weatherCache.now(), Sources: []string{sourceWeatherAPI}, CacheKey: key}, nil
+	}
+
+	// Stale-while-error: "não encontrado" é uma resposta definitiva e não usa o cache
+	if !cacheDisabled && !errors.Is(err, errCannotFindZip) {
+		if cached, age, ok := weatherCache.get(key); ok {
+			slog.WarnContext(ctx, "WeatherAPI failed, serving stale reading", "query", query, "age", age.Round(time.Second), "error", err)
+			return &weatherReading{WeatherAPIResponse: cached, Stale: true, Degraded: degraded, FetchedAt: weatherCache.now().Add(-age), Sources: cachedSources([]string{sourceWeatherAPI}), CacheKey: key}, nil
+		}
+	}
+
+	// Coordenadas sem correspondência não significam que o CEP não existe
+	if location.Coordinates != nil && errors.Is(err, errCannotFindZip) {
+		return nil, errNoWeatherStation
+	}
+	return nil, err
 }
 
-// getWeatherForCity busca a temperatura atual (Celsius) para uma cidade usando a WeatherAPI
-func getWeatherForCity(ctx context.Context, cityName string) (float64, error) {
-	// Codifica o nome da cidade para ser seguro na URL
-	encodedCityName := url.QueryEscape(cityName)
-	weatherRequestURL := fmt.Sprintf(weatherAPIURLFormat, weatherAPIURL, weatherAPIKey, encodedCityName)
+// weatherQuery monta o parâmetro "q" da WeatherAPI: as coordenadas, quando conhecidas, ou
+// "{cidade},{UF},Brazil", que distingue cidades homônimas (ex: São Francisco em MG e SP).
+// Sem UF, consulta apenas pelo nome da cidade.
+func weatherQuery(location cepLocation) string {
+	if location.Coordinates != nil {
+		return location.Coordinates.String()
+	}
+	uf := strings.TrimSpace(location.UF)
+	if uf == "" {
+		return location.City
+	}
+	return location.City + "," + strings.ToUpper(uf) + ",Brazil"
+}
+
+// cityNameParticles são as preposições que ficam em minúsculas nos nomes de cidades
+// (ex: "Rio de Janeiro", "Embu das Artes")
+var cityNameParticles = map[string]bool{"da": true, "das": true, "de": true, "do": true, "dos": true, "e": true}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, weatherRequestURL, nil)
+// normalizeCityName remove espaços nas pontas e repetidos no meio do nome da cidade. Nomes
+// inteiramente em maiúsculas ou minúsculas (ex: "SAO PAULO") recebem capitalização de
+// título; os demais são mantidos. Acentos são preservados, pois a WeatherAPI os entende.
+func normalizeCityName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if name != strings.ToUpper(name) && name != strings.ToLower(name) {
+		return name
+	}
+
+	words := strings.Split(strings.ToLower(name), " ")
+	for i, word := range words {
+		if i > 0 && cityNameParticles[word] {
+			continue
+		}
+		// Partes de nomes compostos também são capitalizadas (ex: "Embu-Guaçu")
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			if part == "" {
+				continue
+			}
+			runes := []rune(part)
+			parts[j] = strings.ToUpper(string(runes[0])) + string(runes[1:])
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " ")
+}
+
+// fetchWeather consulta a WeatherAPI ("q" pode ser o nome da cidade ou "lat,lon"),
+// solicitando a qualidade do ar apenas quando necessário
+func fetchWeather(ctx context.Context, client *http.Client, query string, includeAirQuality bool) (*WeatherAPIResponse, error) {
+	// Codifica a consulta para ser segura na URL
+	encodedQuery := url.QueryEscape(query)
+	weatherRequestURL := fmt.Sprintf(weatherAPIURLFormat, weatherAPIURL, weatherAPIKey, encodedQuery, weatherAPIAQIParam(includeAirQuality))
+
+	var weatherResp WeatherAPIResponse
+	if err := callWeatherAPI(ctx, client, weatherRequestURL, query, &weatherResp); err != nil {
+		return nil, err
+	}
+
+	// Uma temperatura impossível é tratada como falha do provedor: não é cacheada nem convertida
+	if !isPhysicalTemperature(weatherResp.Current.TempC) {
+		return nil, fmt.Errorf("%w: %v°C for %q", errImpossibleTemp, weatherResp.Current.TempC, query)
+	}
+
+	if isOutsideBrazil(weatherResp.Location.Country) {
+		slog.WarnContext(ctx, "WeatherAPI resolved location outside Brazil", "query", query, "region", weatherResp.Location.Region, "country", weatherResp.Location.Country)
+	}
+	slog.InfoContext(ctx, "Weather resolved", "query", query, "temp_c", weatherResp.Current.TempC)
+	return &weatherResp, nil
+}
+
+// newUpstreamRequest cria uma requisição GET para uma API externa, identificando o serviço
+// pelo User-Agent configurado (alguns provedores limitam o user agent padrão do Go)
+func newUpstreamRequest(ctx context.Context, requestURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", httpUserAgent)
+	return req, nil
+}
+
+// weatherAPIPayload é implementado pelas respostas da WeatherAPI, que carregam a estrutura de erro
+type weatherAPIPayload interface {
+	weatherAPIError() *WeatherAPIError
+}
+
+// weatherAPIError retorna o erro informado no corpo da resposta, se houver
+func (w *WeatherAPIResponse) weatherAPIError() *WeatherAPIError {
+	return w.Error
+}
+
+// callWeatherAPIOnce executa uma chamada à WeatherAPI e decodifica a resposta em out,
+// mapeando o código de "localização não encontrada" para errCannotFindZip e os limites
+// do plano (429, 2007 e 2008) para um rateLimitedError
+func callWeatherAPIOnce(ctx context.Context, client *http.Client, requestURL, query string, out weatherAPIPayload) error {
+	if err := consumeAttempt(ctx); err != nil {
+		return err
+	}
+
+	// Cada tentativa tem o seu próprio prazo, limitado pelo prazo da requisição
+	callCtx, cancel := withUpstreamTimeout(ctx, weatherAPITimeout)
+	defer cancel()
+
+	req, err := newUpstreamRequest(callCtx, requestURL)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create WeatherAPI request: %w", err)
+		return fmt.Errorf("failed to create WeatherAPI request: %w", err)
 	}
 
-	resp, err := httpClient.Do(req)
+	start := time.Now()
+	resp, err := doUpstreamRequest(client, req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute WeatherAPI request: %w", err)
+		return fmt.Errorf("failed to execute WeatherAPI request: %w", err)
 	}
 	defer resp.Body.Close()
+	logUpstreamResponse(ctx, "weatherapi", resp.StatusCode, start)
+	logUpstreamBody(ctx, "weatherapi", resp)
+
+	// Limite do plano excedido: o corpo não é decodificado, para que uma nova tentativa
+	// encontre out intacto
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return newRateLimitedError(resp)
+	}
+	// Falha do provedor: também sem decodificar, pois pode passar numa nova tentativa
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: WeatherAPI request failed with status %s", errUpstreamServerError, resp.Status)
+	}
 
 	// WeatherAPI retorna erros no corpo JSON, mesmo com status 200 OK às vezes,
 	// mas também usa códigos de status HTTP para erros (ex: 400, 401, 403).
 	// Precisamos decodificar a resposta para verificar ambos.
 
-	var weatherResp WeatherAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weatherResp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 		// Se falhar a decodificação, verifica o status code HTTP
 		if resp.StatusCode != http.StatusOK {
-			return 0, fmt.Errorf("WeatherAPI request failed with status %s and couldn't decode error body", resp.Status)
+			return fmt.Errorf("WeatherAPI request failed with status %s and couldn't decode error body", resp.Status)
 		}
 		// Se o status for OK, mas não decodificou, é um erro inesperado no formato da resposta
-		return 0, fmt.Errorf("failed to decode WeatherAPI response even with status OK: %w", err)
+		return fmt.Errorf("failed to decode WeatherAPI response even with status OK: %w", err)
 	}
 
 	// Verifica se há um erro na estrutura da resposta JSON
-	if weatherResp.Error != nil {
+	if apiErr := out.weatherAPIError(); apiErr != nil {
+		// No modo estrito, um 200 com erro no corpo é sinalizado: indica mau comportamento
+		// da WeatherAPI ou uma consulta malformada do nosso lado
+		if weatherAPIStrict && resp.StatusCode == http.StatusOK {
+			slog.WarnContext(ctx, "WeatherAPI returned an error body with HTTP 200", "query", query, "code", apiErr.Code, "message", apiErr.Message)
+		}
 		// Verifica se o erro é específico de cidade não encontrada
-		if weatherResp.Error.Code == weatherAPINotFoundCode {
-			log.Printf("WeatherAPI could not find city '%s'. Error code: %d, Message: %s", cityName, weatherResp.Error.Code, weatherResp.Error.Message)
-			return 0, fmt.Errorf(errorCannotFindZip) // Mapeia para o erro 404 da nossa API
+		if apiErr.Code == weatherAPINotFoundCode {
+			slog.InfoContext(ctx, "WeatherAPI could not find location", "query", query, "code", apiErr.Code, "message", apiErr.Message)
+			return errCannotFindZip // Mapeia para o erro 404 da nossa API
+		}
+		if isWeatherAPIRateLimitCode(apiErr.Code) {
+			return &rateLimitedError{status: resp.StatusCode, code: apiErr.Code}
 		}
 		// Outro erro da WeatherAPI
-		return 0, fmt.Errorf("WeatherAPI error: code %d, message: %s", weatherResp.Error.Code, weatherResp.Error.Message)
+		return fmt.Errorf("WeatherAPI error: code %d, message: %s", apiErr.Code, apiErr.Message)
 	}
 
 	// Verifica o status HTTP também, como uma camada extra
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("WeatherAPI request failed with status: %s (but no error structure in body)", resp.Status)
+		return fmt.Errorf("WeatherAPI request failed with status: %s (but no error structure in body)", resp.Status)
 	}
-
-	log.Printf("Weather for city %s: %.1f°C", cityName, weatherResp.Current.TempC)
-	return weatherResp.Current.TempC, nil
-}
-
-// celsiusToFahrenheit converte Celsius para Fahrenheit
-func celsiusToFahrenheit(celsius float64) float64 {
-	// F = C * 1.8 + 32
-	fahrenheit := celsius*1.8 + 32
-	// Arredondar para 1 casa decimal, se necessário (opcional, mas bom para consistência)
-	return roundFloat(fahrenheit, 1)
+	return nil
 }
 
-// celsiusToKelvin converte Celsius para Kelvin
-func celsiusToKelvin(celsius float64) float64 {
-	// K = C + 273 (conforme especificado, embora 273.15 seja mais preciso)
-	kelvin := celsius + 273
-	return roundFloat(kelvin, 1) // Arredondar também
+// isPhysicalTemperature informa se a temperatura em Celsius não está abaixo do zero absoluto
+// (NaN também é rejeitado)
+func isPhysicalTemperature(celsius float64) bool {
+	return celsius >= absoluteZeroCelsius
 }
 
-// roundFloat arredonda um float para um número específico de casas decimais
+// roundFloat arredonda um float para um número específico de casas decimais.
+// Usa math.Round, que arredonda a metade para longe do zero de forma simétrica
+// (ex: -2.55 -> -2.6), já que temperaturas convertidas podem ser negativas.
 func roundFloat(val float64, precision uint) float64 {
-	ratio := float64(1)
-	for i := uint(0); i < precision; i++ {
-		ratio *= 10
+	ratio := math.Pow(10, float64(precision))
+	rounded := math.Round(val*ratio) / ratio
+	if rounded == 0 {
+		return 0 // Evita "-0" na resposta para valores como -0.04
 	}
-	return float64(int(val*ratio+0.5)) / ratio
+	return rounded
 }
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	_ "io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Mock HTTP server para simular ViaCEP e WeatherAPI
@@ -20,18 +30,98 @@ var (
 	mockViaCEPStatusCode     int
 	mockWeatherAPIResponse   string
 	mockWeatherAPIStatusCode int
-	expectWeatherAPICity     string // Para verificar se a cidade correta está sendo passada
+	expectWeatherAPICity     string            // Para verificar se a cidade correta está sendo passada
+	expectWeatherAPIAQI      string            // Para verificar o parâmetro aqi enviado ("yes" ou "no")
+	mockViaCEPByCEP          map[string]string // Respostas específicas por CEP (sobrepõem mockViaCEPResponse)
+	mockBrasilAPIResponse    string
+	mockBrasilAPIStatusCode  int
+	mockForecastResponse     string
+	mockForecastStatusCode   int
+	mockForecastLastDays     string        // Valor do parâmetro days recebido na última chamada
+	mockViaCEPDelay          time.Duration // Atraso simulado antes de cada resposta
+	mockWeatherAPIDelay      time.Duration
+	mockWeatherAPIRetryAfter string // Cabeçalho Retry-After enviado nas respostas 429
+	mockViaCEPRetryAfter     string // Cabeçalho Retry-After enviado nas respostas 429 do ViaCEP
+	mockOpenMeteoResponse    string
+	mockOpenMeteoStatusCode  int
+	mockOpenMeteoLastCoords  string // "latitude,longitude" recebidos na última chamada à Open-Meteo
+
+	// Contadores de chamadas recebidas pelo mock (atômicos, pois há requisições concorrentes)
+	mockViaCEPCalls     atomic.Int32
+	mockWeatherAPICalls atomic.Int32
+	mockBrasilAPICalls  atomic.Int32
+	mockForecastCalls   atomic.Int32
+	mockOpenMeteoCalls  atomic.Int32
+
+	// mockWeatherAPIThrottled é a quantidade de chamadas seguintes à WeatherAPI respondidas com 429
+	mockWeatherAPIThrottled atomic.Int32
+
+	// mockViaCEPThrottled é a quantidade de chamadas seguintes ao ViaCEP respondidas com 429
+	mockViaCEPThrottled atomic.Int32
+
+	// mockUserAgents guarda o último User-Agent recebido por provedor ("viacep", "weatherapi", "brasilapi")
+	mockUserAgents sync.Map
+
+	// mockInFlight é mantido (em leitura) por cada chamada ao mock enquanto ela executa. Uma
+	// chamada lenta abandonada pelo cliente (ex: prazo esgotado) ainda lê as variáveis acima;
+	// setup() espera que ela termine antes de resetá-las para o próximo teste.
+	mockInFlight sync.RWMutex
 )
 
 // mockHandler simula as APIs externas
 func mockHandler(w http.ResponseWriter, r *http.Request) {
+	mockInFlight.RLock()
+	defer mockInFlight.RUnlock()
+
 	if strings.Contains(r.URL.Path, "/ws/") { // ViaCEP request
+		mockViaCEPCalls.Add(1)
+		mockUserAgents.Store("viacep", r.UserAgent())
+		mockDelay(r, mockViaCEPDelay)
+		if mockViaCEPThrottled.Add(-1) >= 0 {
+			if mockViaCEPRetryAfter != "" {
+				w.Header().Set("Retry-After", mockViaCEPRetryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
 		if mockViaCEPStatusCode == 0 {
 			mockViaCEPStatusCode = http.StatusOK // Default
 		}
+		// Ex: /ws/01001000/json/ -> CEP "01001000"
+		cep := strings.Split(strings.TrimPrefix(r.URL.Path, "/ws/"), "/")[0]
 		w.WriteHeader(mockViaCEPStatusCode)
+		if response, ok := mockViaCEPByCEP[cep]; ok {
+			fmt.Fprintln(w, response)
+			return
+		}
 		fmt.Fprintln(w, mockViaCEPResponse)
+	} else if strings.Contains(r.URL.Path, "/api/cep/v2/") { // BrasilAPI request (coordenadas)
+		mockBrasilAPICalls.Add(1)
+		mockUserAgents.Store("brasilapi", r.UserAgent())
+		w.WriteHeader(mockBrasilAPIStatusCode)
+		fmt.Fprintln(w, mockBrasilAPIResponse)
+	} else if r.URL.Path == "/v1/forecast" { // Open-Meteo request
+		mockOpenMeteoCalls.Add(1)
+		mockOpenMeteoLastCoords = r.URL.Query().Get("latitude") + "," + r.URL.Query().Get("longitude")
+		w.WriteHeader(mockOpenMeteoStatusCode)
+		fmt.Fprintln(w, mockOpenMeteoResponse)
+	} else if strings.Contains(r.URL.Path, "/v1/forecast.json") { // WeatherAPI forecast request
+		mockForecastCalls.Add(1)
+		mockForecastLastDays = r.URL.Query().Get("days")
+		w.WriteHeader(mockForecastStatusCode)
+		fmt.Fprintln(w, mockForecastResponse)
 	} else if strings.Contains(r.URL.Path, "/v1/current.json") { // WeatherAPI request
+		mockWeatherAPICalls.Add(1)
+		mockUserAgents.Store("weatherapi", r.UserAgent())
+		mockDelay(r, mockWeatherAPIDelay)
+		if mockWeatherAPIThrottled.Add(-1) >= 0 {
+			if mockWeatherAPIRetryAfter != "" {
+				w.Header().Set("Retry-After", mockWeatherAPIRetryAfter)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintln(w, `{"error": {"code": 2006, "message": "Too many requests."}}`)
+			return
+		}
 		if mockWeatherAPIStatusCode == 0 {
 			mockWeatherAPIStatusCode = http.StatusOK // Default
 		}
@@ -43,6 +133,12 @@ func mockHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if aqi := r.URL.Query().Get("aqi"); expectWeatherAPIAQI != "" && aqi != expectWeatherAPIAQI {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": {"code": 9999, "message": "Expected aqi=%s but got %s"}}`, expectWeatherAPIAQI, aqi)
+			return
+		}
+
 		w.WriteHeader(mockWeatherAPIStatusCode)
 		fmt.Fprintln(w, mockWeatherAPIResponse)
 	} else {
@@ -50,23 +146,107 @@ func mockHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// mockDelay simula uma API lenta, desistindo se o cliente cancelar a requisição
+func mockDelay(r *http.Request, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+	}
+}
+
 func setup() {
 	if mockServer == nil {
 		mockServer = httptest.NewServer(http.HandlerFunc(mockHandler))
-		httpClient = mockServer.Client()
-		httpClient.Timeout = requestTimeout
+		client := mockServer.Client()
+		client.Timeout = requestTimeout
+		defaultClients = newUpstreamClients(client)
 		// Redireciona chamadas para o servidor mock durante os testes
 		viaCEPURL = mockServer.URL
 		weatherAPIURL = mockServer.URL
+		brasilAPIURL = mockServer.URL
+		openMeteoURL = mockServer.URL
 		weatherAPIKey = "be4bd84912cb4b25803234739252104"
 	}
 
-	// Reseta os mocks
+	// Reseta os mocks, depois que as chamadas do teste anterior terminarem
+	mockInFlight.Lock()
+	defer mockInFlight.Unlock()
 	mockViaCEPResponse = ""
 	mockViaCEPStatusCode = http.StatusOK
 	mockWeatherAPIResponse = ""
 	mockWeatherAPIStatusCode = http.StatusOK
 	expectWeatherAPICity = ""
+	expectWeatherAPIAQI = ""
+	mockViaCEPByCEP = nil
+	mockBrasilAPIResponse = ""
+	mockBrasilAPIStatusCode = http.StatusNotFound // Por padrão sem coordenadas, consultando pelo nome da cidade
+	mockViaCEPCalls.Store(0)
+	mockWeatherAPICalls.Store(0)
+	mockBrasilAPICalls.Store(0)
+	mockForecastCalls.Store(0)
+	mockUserAgents.Clear()
+	httpUserAgent = defaultHTTPUserAgent
+	mockForecastResponse = ""
+	mockForecastStatusCode = http.StatusOK
+	mockForecastLastDays = ""
+	mockViaCEPDelay = 0
+	mockWeatherAPIDelay = 0
+	mockWeatherAPIThrottled.Store(0)
+	mockWeatherAPIRetryAfter = ""
+	mockViaCEPThrottled.Store(0)
+	mockViaCEPRetryAfter = ""
+	mockOpenMeteoResponse = ""
+	mockOpenMeteoStatusCode = http.StatusOK
+	mockOpenMeteoLastCoords = ""
+	mockOpenMeteoCalls.Store(0)
+
+	// Restaura a configuração padrão, que alguns testes alteram
+	maxFallbackAttempts = defaultMaxFallbackAttempts
+	debugEndpoints = false
+	batchConcurrency = defaultBatchConcurrency
+	batchMaxSize = defaultBatchMaxSize
+	maxCEPsPerRequest = defaultMaxCEPsPerRequest
+	forecastMaxDays = defaultForecastMaxDays
+	clientRateLimiter = nil
+	routeRateLimiters = nil
+	weatherBreaker = nil
+	weatherErrorRate = nil
+	weatherCacheTTL = 0 // Sem cache fresco: cada teste controla as respostas da WeatherAPI
+	staleGracePeriod = defaultStaleGracePeriod
+	weatherCache.clear()
+	weatherCache.now = time.Now
+	cepCacheTTL = 0 // Os testes alteram a resposta do ViaCEP entre requisições ao mesmo CEP
+	cepCache.clear()
+	logRequestMetadata = true
+	logUpstreamBodies = false
+	maxPathLength = defaultMaxPathLength
+	basePath = ""
+	allowedCEPPrefixes = nil
+	deniedCEPPrefixes = nil
+	viaCEPTimeout = requestTimeout
+	weatherAPITimeout = requestTimeout
+	accessLogEnabled = false
+	forwardedSkipPrivate = true
+	trustedProxies = nil
+	localCEPDB = nil
+	cepDBFallthrough = true
+	gzipMinSize = defaultGzipMinSize
+	validateMaxSize = defaultValidateMaxSize
+	cacheDisabled = false
+	responseHMACSecret = nil
+	totalRequestBudget = defaultTotalRequestBudget
+	weatherAPIStrict = false
+	serverStats.reset()
+	httpRetryPolicy = retryPolicy{} // Sem novas tentativas: cada teste controla as falhas da WeatherAPI
+	weatherAPIMaxRetryAfter = defaultWeatherAPIMaxRetryAfter
+	responseCacheMaxAge = defaultResponseCacheMaxAge
+	tracerProvider = noop.NewTracerProvider()
+	upstreamSlots = nil
+	temperatureConverter = standardConverter{}
+	cepFallback = cepFallbackNone
 }
 
 // teardown fecha o mock server após todos os testes
@@ -103,7 +283,7 @@ func TestWeatherHandler_Success(t *testing.T) {
 	// Configura as respostas do mock
 	mockViaCEPResponse = fmt.Sprintf(`{"cep": "01001-000", "logradouro": "Praça da Sé", "complemento": "lado ímpar", "bairro": "Sé", "localidade": "%s", "uf": "SP", "ibge": "3550308", "gia": "1004", "ddd": "11", "siafi": "7107"}`, expectedCity)
 	mockWeatherAPIResponse = fmt.Sprintf(`{"location": {"name": "%s"}, "current": {"temp_c": %.1f}}`, expectedCity, expectedTempC)
-	expectWeatherAPICity = expectedCity // Garante que a cidade correta foi passada para WeatherAPI
+	expectWeatherAPICity = expectedCity + ",SP,Brazil" // Garante que a cidade (com a UF) foi passada para WeatherAPI
 
 	req := httptest.NewRequest(http.MethodGet, "/weather/"+cep, nil)
 	rr := httptest.NewRecorder() // Recorder para capturar a resposta
@@ -135,7 +315,7 @@ func TestWeatherHandler_Success(t *testing.T) {
 		TempK: celsiusToKelvin(expectedTempC),
 	}
 
-	if actualResponse != expectedResponse {
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
 		t.Errorf("handler returned unexpected body: got %+v want %+v", actualResponse, expectedResponse)
 	}
 }
@@ -167,6 +347,189 @@ func TestWeatherHandler_InvalidCEPFormat(t *testing.T) {
 	}
 }
 
+func TestIsValidCEP(t *testing.T) {
+	testCases := []struct {
+		cep      string
+		expected bool
+	}{
+		{"01001000", true},  // Praça da Sé, início da faixa atribuída
+		{"99999999", true},  // Bem formado e em faixa atribuída: decidido pelo ViaCEP
+		{"00000000", false}, // Todos os dígitos zero
+		{"00999999", false}, // Abaixo de 01000-000
+		{"0100100", false},
+		{"0100100a", false},
+		{"０１００１０００", false}, // Dígitos de largura total
+		{"0100100０", false}, // Um único dígito de largura total
+		{"٠١٠٠١٠٠٠", false}, // Dígitos arábico-índicos
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.cep, func(t *testing.T) {
+			if valid := isValidCEP(tc.cep); valid != tc.expected {
+				t.Errorf("isValidCEP(%q) = %v, want %v", tc.cep, valid, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_FullWidthDigitCEP(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/"+url.PathEscape("０１００１０００"), nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if actualBody := strings.TrimSpace(rr.Body.String()); actualBody != errorInvalidZipcode {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", actualBody, errorInvalidZipcode)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no ViaCEP calls for a full-width digit CEP, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_ObservedAt(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+
+	testCases := []struct {
+		name     string
+		weather  string
+		accept   string
+		expected string // Trecho esperado no corpo; vazio indica que o campo deve ser omitido
+	}{
+		{"json", `{"current": {"temp_c": 20.0, "last_updated_epoch": 1745238600}}`, "", `"observed_at":"2025-04-21T12:30:00Z"`},
+		{"xml", `{"current": {"temp_c": 20.0, "last_updated_epoch": 1745238600}}`, "application/xml", `<observed_at>2025-04-21T12:30:00Z</observed_at>`},
+		{"missing epoch", `{"current": {"temp_c": 20.0}}`, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWeatherAPIResponse = tc.weather
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			body := rr.Body.String()
+			if tc.expected == "" {
+				if strings.Contains(body, "observed_at") {
+					t.Errorf("expected observed_at to be omitted, got %s", body)
+				}
+				return
+			}
+			if !strings.Contains(body, tc.expected) {
+				t.Errorf("expected %s in the body, got %s", tc.expected, body)
+			}
+		})
+	}
+}
+
+func TestHasNonASCIIDigit(t *testing.T) {
+	testCases := []struct {
+		cep      string
+		expected bool
+	}{
+		{"01001000", false},
+		{"0100100a", false},
+		{"０１００１０００", true},
+		{"0100100０", true},
+		{"٠١٠٠١٠٠٠", true},
+	}
+
+	for _, tc := range testCases {
+		if got := hasNonASCIIDigit(tc.cep); got != tc.expected {
+			t.Errorf("hasNonASCIIDigit(%q) = %v, want %v", tc.cep, got, tc.expected)
+		}
+	}
+}
+
+func TestWeatherHandler_UnassignedCEP(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/00000000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorInvalidZipcode {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInvalidZipcode)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected unassigned CEP not to reach ViaCEP, got %d calls", calls)
+	}
+}
+
+func TestWeatherHandler_MalformedPath(t *testing.T) {
+	setup()
+	defer teardown()
+
+	paths := []string{"/weather/01001000/extra", "/weather/01001000/extra/more", "/weather/", "/weather/01001000//"}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorMalformedPath {
+				t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorMalformedPath)
+			}
+		})
+	}
+
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected malformed paths not to reach ViaCEP, got %d calls", calls)
+	}
+}
+
+func TestWeatherHandler_TrailingSlash(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000/", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+	}
+
+	// Um CEP válido mas inexistente continua sendo 404
+	mockViaCEPResponse = `{"erro": true}`
+	rr = httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/99999999/", nil))
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorCannotFindZip {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorCannotFindZip)
+	}
+}
+
 func TestWeatherHandler_CEPNotFound_ViaCEP(t *testing.T) {
 	setup()
 	defer teardown()
@@ -226,6 +589,45 @@ func TestWeatherHandler_CEPNotFound_WeatherAPI(t *testing.T) {
 	}
 }
 
+func TestWeatherHandler_ViaCEPInvalidBody(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"empty body", "", http.StatusBadGateway, errorBadUpstreamResponse},
+		{"garbage body", "<html>Service Unavailable</html>", http.StatusBadGateway, errorBadUpstreamResponse},
+		{"truncated JSON", `{"localidade": "São`, http.StatusBadGateway, errorBadUpstreamResponse},
+		{"legitimate erro", `{"erro": true}`, http.StatusNotFound, errorCannotFindZip},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = tc.body
+			mockViaCEPStatusCode = http.StatusOK
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, tc.expectedBody)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected WeatherAPI not to be called, got %d calls", calls)
+			}
+		})
+	}
+}
+
 // Teste para simular um erro interno no ViaCEP (ex: timeout, 5xx)
 func TestWeatherHandler_InternalError_ViaCEP(t *testing.T) {
 	setup()
@@ -284,3 +686,655 @@ func TestWeatherHandler_InternalError_WeatherAPI(t *testing.T) {
 		t.Errorf("handler returned unexpected body: got '%s' want '%s'", actualBody, expectedBody)
 	}
 }
+
+func TestWeatherHandler_ExtendedPrecipitation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cep := "01001000"
+	cityFromViaCEP := "São Paulo"
+
+	mockViaCEPResponse = fmt.Sprintf(`{"localidade": "%s"}`, cityFromViaCEP)
+	expectWeatherAPICity = cityFromViaCEP
+
+	testCases := []struct {
+		name           string
+		precip         string
+		query          string
+		expectPresent  bool
+		expectedPrecip float64
+	}{
+		{"extended with rain", `, "precip_mm": 3.2`, "?extended=true", true, 3.2},
+		{"extended with real zero", `, "precip_mm": 0`, "?extended=true", true, 0},
+		{"default mode omits field", `, "precip_mm": 3.2`, "", false, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWeatherAPIResponse = fmt.Sprintf(`{"current": {"temp_c": 20.0%s}}`, tc.precip)
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/"+cep+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+
+			precip, present := body["precip_mm"]
+			if present != tc.expectPresent {
+				t.Fatalf("precip_mm presence: got %v want %v (body %v)", present, tc.expectPresent, body)
+			}
+			if present && precip.(float64) != tc.expectedPrecip {
+				t.Errorf("precip_mm: got %v want %v", precip, tc.expectedPrecip)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_OnlyCity(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?only_city=true", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var actualResponse CityResponse
+	if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+
+	expectedResponse := CityResponse{City: "São Paulo", UF: "SP"}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("handler returned unexpected body: got %+v want %+v", actualResponse, expectedResponse)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 0 {
+		t.Errorf("expected no WeatherAPI call in only_city mode, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_WholeKelvin(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	// 24.45 + 273 = 297.45: arredondar primeiro para 297.5 e depois para inteiro daria 298
+	mockWeatherAPIResponse = `{"current": {"temp_c": 24.45}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?whole_kelvin=true", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var actualResponse WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+
+	if actualResponse.TempK != 297 {
+		t.Errorf("expected whole Kelvin 297, got %v", actualResponse.TempK)
+	}
+	if actualResponse.TempC != 24.45 || actualResponse.TempF != celsiusToFahrenheit(24.45) {
+		t.Errorf("expected Celsius/Fahrenheit to keep decimals, got C=%v F=%v", actualResponse.TempC, actualResponse.TempF)
+	}
+}
+
+func TestWeatherHandler_ExtendedResolvedLocation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// O ViaCEP grafa a cidade sem acento; a WeatherAPI resolve para o nome canônico
+	mockViaCEPResponse = `{"localidade": "Sao Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"location": {"name": "São Paulo", "region": "Sao Paulo", "country": "Brazil"}, "current": {"temp_c": 21.0}}`
+
+	testCases := []struct {
+		query            string
+		expectedLocation string
+	}{
+		{"?extended=true", "São Paulo"},
+		{"", ""}, // Omitido fora do modo estendido
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			var body map[string]any
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			resolved, present := body["resolved_location"]
+			if tc.expectedLocation == "" && present {
+				t.Errorf("expected resolved_location to be omitted, got %v", resolved)
+			}
+			if tc.expectedLocation != "" && resolved != tc.expectedLocation {
+				t.Errorf("got resolved_location %v want %q", resolved, tc.expectedLocation)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_ExtendedRegionAndCountry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "Palmas"}`
+
+	testCases := []struct {
+		name                string
+		location            string
+		expectedRegion      string
+		expectedCountry     string
+		expectOutsideBrazil bool
+	}{
+		{"Brazilian match", `{"name": "Palmas", "region": "Tocantins", "country": "Brazil"}`, "Tocantins", "Brazil", false},
+		{"wrong-country match", `{"name": "Palmas", "region": "Canary Islands", "country": "Spain"}`, "Canary Islands", "Spain", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWeatherAPIResponse = fmt.Sprintf(`{"location": %s, "current": {"temp_c": 27.0}}`, tc.location)
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/77001002?extended=true", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			var actualResponse WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if actualResponse.Region != tc.expectedRegion || actualResponse.Country != tc.expectedCountry {
+				t.Errorf("got region=%q country=%q want region=%q country=%q", actualResponse.Region, actualResponse.Country, tc.expectedRegion, tc.expectedCountry)
+			}
+			if actualResponse.OutsideBrazil != tc.expectOutsideBrazil {
+				t.Errorf("outside_brazil: got %v want %v", actualResponse.OutsideBrazil, tc.expectOutsideBrazil)
+			}
+		})
+	}
+}
+
+func TestCelsiusToRankine(t *testing.T) {
+	testCases := []struct {
+		celsius  float64
+		expected float64
+	}{
+		{0, 491.7},      // Ponto de congelamento da água: 491.67°R
+		{100, 671.7},    // Ponto de ebulição da água: 671.67°R
+		{-273.15, 0},    // Zero absoluto
+		{25, 536.7},     // 536.67°R
+		{-40, 419.7},    // 419.67°R
+		{-17.78, 459.7}, // ~0°F: 459.666°R
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v°C", tc.celsius), func(t *testing.T) {
+			if rankine := celsiusToRankine(tc.celsius); rankine != tc.expected {
+				t.Errorf("celsiusToRankine(%v) = %v, want %v", tc.celsius, rankine, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		location cepLocation
+		expected string
+	}{
+		{"city and UF", cepLocation{City: "São Francisco", UF: "MG"}, "São Francisco,MG,Brazil"},
+		{"lowercase UF", cepLocation{City: "São Francisco", UF: " sp "}, "São Francisco,SP,Brazil"},
+		{"without UF", cepLocation{City: "São Francisco"}, "São Francisco"},
+		{"blank UF", cepLocation{City: "São Francisco", UF: "  "}, "São Francisco"},
+		{"coordinates win", cepLocation{City: "São Francisco", UF: "MG", Coordinates: &coordinates{Lat: -15.95, Lon: -44.86}}, "-15.95,-44.86"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if query := weatherQuery(tc.location); query != tc.expected {
+				t.Errorf("weatherQuery(%+v) = %q, want %q", tc.location, query, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeCityName(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected string
+	}{
+		{"São Paulo", "São Paulo"},
+		{"  São Paulo  ", "São Paulo"},
+		{"\tRio  de   Janeiro\n", "Rio de Janeiro"},
+		{"SÃO PAULO", "São Paulo"},
+		{"rio de janeiro", "Rio de Janeiro"},
+		{"EMBU-GUAÇU", "Embu-Guaçu"},
+		{"EMBU DAS ARTES", "Embu das Artes"},
+		{"Santana do Parnaíba", "Santana do Parnaíba"},
+		{"   ", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := normalizeCityName(tc.raw); got != tc.expected {
+			t.Errorf("normalizeCityName(%q) = %q, want %q", tc.raw, got, tc.expected)
+		}
+	}
+}
+
+func TestWeatherHandler_TrimsViaCEPLocalidade(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "  São Paulo \t", "uf": " SP "}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 24.0}}`
+	expectWeatherAPICity = "São Paulo,SP,Brazil"
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?only_city=true", nil))
+	if body := strings.TrimSpace(rr.Body.String()); body != `{"city":"São Paulo","uf":"SP"}` {
+		t.Errorf("expected the trimmed city, got %q", body)
+	}
+
+	rr = httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected the trimmed city in the WeatherAPI query, got status %v (body %q)", rr.Code, rr.Body.String())
+	}
+
+	// Uma localidade apenas com espaços equivale a um CEP não encontrado
+	mockViaCEPResponse = `{"localidade": "   "}`
+	rr = httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("blank localidade: got status %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestWeatherHandler_QueryIncludesUF(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockWeatherAPIResponse = `{"current": {"temp_c": 24.0}}`
+
+	testCases := []struct {
+		viaCEP        string
+		expectedQuery string
+	}{
+		{`{"localidade": "São Francisco", "uf": "MG"}`, "São Francisco,MG,Brazil"},
+		{`{"localidade": "São Francisco", "uf": ""}`, "São Francisco"},
+		{`{"localidade": "São Francisco"}`, "São Francisco"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expectedQuery, func(t *testing.T) {
+			mockViaCEPResponse = tc.viaCEP
+			expectWeatherAPICity = tc.expectedQuery // O mock rejeita qualquer outra consulta
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/39300000", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v (body %s)", status, http.StatusOK, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestIsPhysicalTemperature(t *testing.T) {
+	testCases := []struct {
+		celsius  float64
+		expected bool
+	}{
+		{25, true},
+		{0, true},
+		{absoluteZeroCelsius, true}, // O próprio zero absoluto é válido
+		{-273.1, false},             // Daria Kelvin negativo com K = C + 273
+		{-273.16, false},
+		{-500, false},
+		{math.NaN(), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v°C", tc.celsius), func(t *testing.T) {
+			if valid := isPhysicalTemperature(tc.celsius); valid != tc.expected {
+				t.Errorf("isPhysicalTemperature(%v) = %v, want %v", tc.celsius, valid, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_AbsoluteZeroBoundary(t *testing.T) {
+	testCases := []struct {
+		tempC        string
+		expectedCode int
+		expectedK    string
+	}{
+		{"-273.0", http.StatusOK, `"temp_K":0.0`},
+		{"-273.1", http.StatusInternalServerError, ""}, // Seria -0.1 K
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tempC, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": ` + tc.tempC + `}}`
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if body := rr.Body.String(); tc.expectedK != "" && !strings.Contains(body, tc.expectedK) {
+				t.Errorf("got body %s want %s", body, tc.expectedK)
+			}
+			if strings.Contains(rr.Body.String(), `"temp_K":-`) {
+				t.Errorf("expected no negative Kelvin, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetWeatherForCity_BelowAbsoluteZero(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockWeatherAPIResponse = `{"current": {"temp_c": -300.0}}`
+
+	weather, err := getWeatherForCity(context.Background(), defaultClients, cepLocation{City: "São Paulo"}, false)
+	if !errors.Is(err, errImpossibleTemp) || weather != nil {
+		t.Fatalf("got (%v, %v) want errImpossibleTemp", weather, err)
+	}
+	if _, _, ok := weatherCache.get(weatherCacheKey("São Paulo")); ok {
+		t.Error("expected impossible reading not to be cached")
+	}
+
+	// No handler a leitura impossível vira um erro interno
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusInternalServerError)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorInternalServer {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInternalServer)
+	}
+}
+
+func TestWeatherHandler_RankineScale(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 0.0}}`
+
+	testCases := []struct {
+		query          string
+		expectedStatus int
+		expectRankine  bool
+	}{
+		{"", http.StatusOK, false},
+		{"?scales=rankine", http.StatusOK, true},
+		{"?scales=all", http.StatusOK, true},
+		{"?scales=rankine,celsius", http.StatusUnprocessableEntity, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var actualResponse WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if (actualResponse.TempR != nil) != tc.expectRankine {
+				t.Fatalf("temp_R presence: got %v want %v", actualResponse.TempR != nil, tc.expectRankine)
+			}
+			if tc.expectRankine && *actualResponse.TempR != 491.7 {
+				t.Errorf("temp_R: got %v want 491.7", *actualResponse.TempR)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_ReaumurAndNewtonScales(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 100.0}}`
+
+	testCases := []struct {
+		query         string
+		expectReaumur bool
+		expectNewton  bool
+		expectRankine bool
+	}{
+		{"?scales=reaumur", true, false, false},
+		{"?scales=newton", false, true, false},
+		{"?scales=reaumur,newton", true, true, false},
+		{"?scales=all", true, true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var actualResponse WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&actualResponse); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if actualResponse.TempC != 100 || actualResponse.TempF != 212 || actualResponse.TempK != 373 {
+				t.Errorf("expected the default scales alongside the extra ones, got %+v", actualResponse)
+			}
+			if (actualResponse.TempRe != nil) != tc.expectReaumur || (actualResponse.TempN != nil) != tc.expectNewton || (actualResponse.TempR != nil) != tc.expectRankine {
+				t.Fatalf("scale presence: got temp_Re=%v temp_N=%v temp_R=%v", actualResponse.TempRe != nil, actualResponse.TempN != nil, actualResponse.TempR != nil)
+			}
+			if tc.expectReaumur && *actualResponse.TempRe != 80 {
+				t.Errorf("temp_Re: got %v want 80", *actualResponse.TempRe)
+			}
+			if tc.expectNewton && *actualResponse.TempN != 33 {
+				t.Errorf("temp_N: got %v want 33", *actualResponse.TempN)
+			}
+		})
+	}
+}
+
+func TestRoundFloat_NegativeValues(t *testing.T) {
+	testCases := []struct {
+		value     float64
+		precision uint
+		expected  float64
+	}{
+		{-2.55, 1, -2.6},
+		{-10.25, 1, -10.3},
+		{-0.05, 1, -0.1},
+		{-40, 1, -40},
+		{-2.5, 0, -3},
+		{2.55, 1, 2.6}, // Simétrico ao caso negativo
+		{2.5, 0, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v@%d", tc.value, tc.precision), func(t *testing.T) {
+			if rounded := roundFloat(tc.value, tc.precision); rounded != tc.expected {
+				t.Errorf("roundFloat(%v, %d) = %v, want %v", tc.value, tc.precision, rounded, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRoundFloat_NoNegativeZero(t *testing.T) {
+	rounded := roundFloat(-0.04, 1)
+	if rounded != 0 || math.Signbit(rounded) {
+		t.Errorf("roundFloat(-0.04, 1) = %v, want positive zero", rounded)
+	}
+}
+
+func TestConversions_NegativeTemperatures(t *testing.T) {
+	testCases := []struct {
+		celsius    float64
+		fahrenheit float64
+		kelvin     float64
+	}{
+		{-2.55, 27.4, 270.5},
+		{-10.25, 13.6, 262.8},
+		{-40, -40, 233},
+		{-17.85, -0.1, 255.2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v°C", tc.celsius), func(t *testing.T) {
+			if f := celsiusToFahrenheit(tc.celsius); f != tc.fahrenheit {
+				t.Errorf("celsiusToFahrenheit(%v) = %v, want %v", tc.celsius, f, tc.fahrenheit)
+			}
+			if k := celsiusToKelvin(tc.celsius); k != tc.kelvin {
+				t.Errorf("celsiusToKelvin(%v) = %v, want %v", tc.celsius, k, tc.kelvin)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_Head(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	testCases := []struct {
+		name           string
+		path           string
+		viaCEP         string
+		expectedStatus int
+	}{
+		{"success", "/weather/01001000", `{"localidade": "São Paulo"}`, http.StatusOK},
+		{"invalid CEP", "/weather/123", `{"localidade": "São Paulo"}`, http.StatusUnprocessableEntity},
+		{"CEP not found", "/weather/99999999", `{"erro": true}`, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockViaCEPResponse = tc.viaCEP
+			req := httptest.NewRequest(http.MethodHead, tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("expected empty body for HEAD, got %q", rr.Body.String())
+			}
+		})
+	}
+
+	// HEAD consulta as APIs externas como o GET
+	if calls := mockWeatherAPICalls.Load(); calls != 1 {
+		t.Errorf("expected 1 WeatherAPI call, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_MethodNotAllowed(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			req := httptest.NewRequest(method, "/weather/01001000", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusMethodNotAllowed {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+			}
+			if allow := rr.Header().Get("Allow"); allow != "GET, HEAD" {
+				t.Errorf("got Allow %q want %q", allow, "GET, HEAD")
+			}
+		})
+	}
+
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected rejected methods not to reach ViaCEP, got %d calls", calls)
+	}
+}
+
+func TestUpstreamRequests_UserAgent(t *testing.T) {
+	testCases := []struct {
+		name      string
+		userAgent string
+	}{
+		{"default", defaultHTTPUserAgent},
+		{"configured", "acme-monitor/2.3 (+https://example.com)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			httpUserAgent = tc.userAgent
+			mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+			mockWeatherAPIResponse = `{"current": {"temp_c": 22.0}}`
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			for _, provider := range []string{"viacep", "brasilapi", "weatherapi"} {
+				userAgent, _ := mockUserAgents.Load(provider)
+				if userAgent != tc.userAgent {
+					t.Errorf("%s: got User-Agent %v want %q", provider, userAgent, tc.userAgent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_OpenAPISpec(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if ctype := rr.Header().Get("Content-Type"); ctype != "application/json" {
+		t.Errorf("handler returned wrong content type: got %s", ctype)
+	}
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Responses map[string]any `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+
+	if spec.OpenAPI == "" {
+		t.Error("missing openapi version")
+	}
+	weatherPath, ok := spec.Paths["/weather/{cep}"]["get"]
+	if !ok {
+		t.Fatal("missing GET /weather/{cep} in spec paths")
+	}
+	for _, status := range []string{"200", "404", "422", "500"} {
+		if _, ok := weatherPath.Responses[status]; !ok {
+			t.Errorf("missing %s response for /weather/{cep}", status)
+		}
+	}
+	if _, ok := spec.Components.Schemas["WeatherResponse"]; !ok {
+		t.Error("missing WeatherResponse schema")
+	}
+}
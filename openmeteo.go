@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	openMeteoURLEnv     = "OPEN_METEO_URL"
+	defaultOpenMeteoURL = "https://api.open-meteo.com"
+	openMeteoURLFormat  = "%s/v1/forecast?latitude=%s&longitude=%s&current=temperature_2m"
+
+	// Nomes dos provedores na resposta de ?consensus=true
+	providerWeatherAPI = "weatherapi"
+	providerOpenMeteo  = "open-meteo"
+)
+
+// openMeteoURL é a URL base da Open-Meteo, segundo provedor de clima do modo consenso
+var openMeteoURL = defaultOpenMeteoURL
+
+// errNoCoordinates indica que não há coordenadas para consultar a Open-Meteo, que não aceita nomes de cidade
+var errNoCoordinates = errors.New(errorNoCoordinates)
+
+// OpenMeteoResponse Struct para a resposta da Open-Meteo com a temperatura atual
+type OpenMeteoResponse struct {
+	Current *struct {
+		Temperature2m float64 `json:"temperature_2m"` // Temperatura a 2 metros do solo, em Celsius
+	} `json:"current"`
+	Error  bool   `json:"error"` // A Open-Meteo sinaliza erros com {"error": true, "reason": "..."}
+	Reason string `json:"reason"`
+}
+
+// ConsensusResponse Struct com a leitura de cada provedor no modo ?consensus=true
+type ConsensusResponse struct {
+	Providers []ProviderTemperature `json:"providers" xml:"provider"`
+}
+
+// ProviderTemperature Struct com a temperatura de um provedor, ou o motivo da falha
+type ProviderTemperature struct {
+	Provider string   `json:"provider" xml:"name,attr"`
+	TempC    *float64 `json:"temp_C,omitempty" xml:"temp_C,omitempty"`
+	Error    string   `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// getOpenMeteoTemperature busca a temperatura atual (em Celsius) nas coordenadas informadas
+func getOpenMeteoTemperature(ctx context.Context, client *http.Client, coords coordinates) (tempC float64, err error) {
+	ctx, span := startSpan(ctx, "getOpenMeteoTemperature", attribute.String("coordinates", coords.String()))
+	defer func() { endSpan(span, err) }()
+
+	if err := consumeAttempt(ctx); err != nil {
+		return 0, err
+	}
+
+	requestURL := fmt.Sprintf(openMeteoURLFormat, openMeteoURL,
+		strconv.FormatFloat(coords.Lat, 'f', -1, 64), strconv.FormatFloat(coords.Lon, 'f', -1, 64))
+	req, err := newUpstreamRequest(ctx, requestURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Open-Meteo request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := doUpstreamRequest(client, req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute Open-Meteo request: %w", err)
+	}
+	defer resp.Body.Close()
+	logUpstreamResponse(ctx, "openmeteo", resp.StatusCode, start)
+	logUpstreamBody(ctx, "openmeteo", resp)
+
+	var payload OpenMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return 0, fmt.Errorf("%w: Open-Meteo body could not be decoded (status %s): %v", errBadUpstreamResponse, resp.Status, err)
+	}
+	if payload.Error || resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Open-Meteo request failed with status %s: %s", resp.Status, payload.Reason)
+	}
+	if payload.Current == nil {
+		return 0, fmt.Errorf("%w: Open-Meteo response without current temperature", errBadUpstreamResponse)
+	}
+	return payload.Current.Temperature2m, nil
+}
+
+// getConsensusWeather consulta a WeatherAPI e a Open-Meteo e combina as leituras: com as duas,
+// a temperatura é a média; com apenas uma, ela é usada sozinha. Os demais campos (umidade,
+// vento etc.) vêm da WeatherAPI. A Open-Meteo usa as coordenadas do CEP ou, na falta delas,
+// as da localização resolvida pela WeatherAPI. Só falha quando os dois provedores falham,
+// devolvendo o erro da WeatherAPI.
+func getConsensusWeather(ctx context.Context, clients upstreamClients, location cepLocation, includeAirQuality bool) (*weatherReading, *ConsensusResponse, error) {
+	type openMeteoResult struct {
+		tempC float64
+		err   error
+	}
+	var openMeteo chan openMeteoResult
+	queryOpenMeteo := func(coords coordinates) {
+		openMeteo = make(chan openMeteoResult, 1)
+		go func() {
+			tempC, err := getOpenMeteoTemperature(ctx, clients.OpenMeteo, coords)
+			openMeteo <- openMeteoResult{tempC, err}
+		}()
+	}
+
+	// Com as coordenadas do CEP, as duas consultas correm em paralelo
+	if location.Coordinates != nil {
+		queryOpenMeteo(*location.Coordinates)
+	}
+	reading, weatherErr := getWeatherForCity(ctx, clients, location, includeAirQuality)
+	if openMeteo == nil && weatherErr == nil && (reading.Location.Lat != 0 || reading.Location.Lon != 0) {
+		queryOpenMeteo(coordinates{Lat: reading.Location.Lat, Lon: reading.Location.Lon})
+	}
+	result := openMeteoResult{err: errNoCoordinates}
+	if openMeteo != nil {
+		result = <-openMeteo
+	}
+
+	consensus := &ConsensusResponse{}
+	var temps []float64
+	if weatherErr == nil {
+		tempC := reading.Current.TempC
+		temps = append(temps, tempC)
+		consensus.Providers = append(consensus.Providers, ProviderTemperature{Provider: providerWeatherAPI, TempC: &tempC})
+	} else {
+		slog.WarnContext(ctx, "Consensus provider failed", "provider", providerWeatherAPI, "query", location.City, "error", weatherErr)
+		consensus.Providers = append(consensus.Providers, ProviderTemperature{Provider: providerWeatherAPI, Error: providerErrorMessage(weatherErr)})
+	}
+	if result.err == nil {
+		tempC := result.tempC
+		temps = append(temps, tempC)
+		consensus.Providers = append(consensus.Providers, ProviderTemperature{Provider: providerOpenMeteo, TempC: &tempC})
+	} else {
+		slog.WarnContext(ctx, "Consensus provider failed", "provider", providerOpenMeteo, "query", location.City, "error", result.err)
+		consensus.Providers = append(consensus.Providers, ProviderTemperature{Provider: providerOpenMeteo, Error: providerErrorMessage(result.err)})
+	}
+
+	switch {
+	case len(temps) == 0:
+		return nil, nil, weatherErr
+	case weatherErr != nil:
+		// Apenas a Open-Meteo respondeu: não há os campos opcionais da WeatherAPI
+		reading = &weatherReading{WeatherAPIResponse: &WeatherAPIResponse{}, FetchedAt: time.Now()}
+	default:
+		// A leitura da WeatherAPI pode vir do cache e é compartilhada; a média vai numa cópia
+		averaged := *reading.WeatherAPIResponse
+		copied := *reading
+		copied.WeatherAPIResponse = &averaged
+		reading = &copied
+	}
+
+	sum := 0.0
+	for _, tempC := range temps {
+		sum += tempC
+	}
+	reading.Current.TempC = roundFloat(sum/float64(len(temps)), 2)
+	if result.err == nil {
+		// Clip evita que o append altere as origens da leitura compartilhada pelo cache
+		reading.Sources = append(slices.Clip(reading.Sources), sourceOpenMeteo)
+	}
+	return reading, consensus, nil
+}
+
+// providerErrorMessage resume a falha de um provedor na resposta, sem expor detalhes internos
+// como as URLs consultadas
+func providerErrorMessage(err error) string {
+	if errors.Is(err, errNoCoordinates) {
+		return errorNoCoordinates
+	}
+	if status, message := upstreamErrorStatus(err); status != http.StatusInternalServerError {
+		return message
+	}
+	return errorProviderUnavailable
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// consensusRequest consulta /weather/{cep}?consensus=true e decodifica a resposta de sucesso
+func consensusRequest(t *testing.T) (*httptest.ResponseRecorder, WeatherResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000?consensus=true", nil))
+
+	var response WeatherResponse
+	if rr.Code == http.StatusOK {
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Could not decode response body: %v", err)
+		}
+	}
+	return rr, response
+}
+
+// providerTemp retorna a leitura de um provedor na resposta do consenso
+func providerTemp(t *testing.T, response WeatherResponse, provider string) ProviderTemperature {
+	t.Helper()
+	if response.Consensus == nil {
+		t.Fatal("expected the consensus block in the response")
+	}
+	for _, entry := range response.Consensus.Providers {
+		if entry.Provider == provider {
+			return entry
+		}
+	}
+	t.Fatalf("provider %q not found in %+v", provider, response.Consensus.Providers)
+	return ProviderTemperature{}
+}
+
+func TestWeatherHandler_ConsensusAveragesProviders(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"location": {"coordinates": {"latitude": "-23.55", "longitude": "-46.63"}}}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0, "humidity": 60}}`
+	mockOpenMeteoResponse = `{"current": {"temperature_2m": 24.1}}`
+
+	rr, response := consensusRequest(t)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if response.TempC != 24.55 || response.TempF != 76.2 || response.TempK != 297.6 {
+		t.Errorf("expected the average in every scale, got C=%v F=%v K=%v", response.TempC, response.TempF, response.TempK)
+	}
+	if entry := providerTemp(t, response, providerWeatherAPI); entry.TempC == nil || *entry.TempC != 25 {
+		t.Errorf("unexpected WeatherAPI entry %+v", entry)
+	}
+	if entry := providerTemp(t, response, providerOpenMeteo); entry.TempC == nil || *entry.TempC != 24.1 {
+		t.Errorf("unexpected Open-Meteo entry %+v", entry)
+	}
+	if mockOpenMeteoLastCoords != "-23.55,-46.63" {
+		t.Errorf("expected Open-Meteo to be queried with the CEP coordinates, got %q", mockOpenMeteoLastCoords)
+	}
+}
+
+func TestWeatherHandler_ConsensusUsesWeatherAPICoordinates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// Sem coordenadas na BrasilAPI: a Open-Meteo usa a localização resolvida pela WeatherAPI
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}, "location": {"lat": -23.53, "lon": -46.62}}`
+	mockOpenMeteoResponse = `{"current": {"temperature_2m": 22.0}}`
+
+	rr, response := consensusRequest(t)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+	if response.TempC != 21 {
+		t.Errorf("expected temp_C 21, got %v", response.TempC)
+	}
+	if mockOpenMeteoLastCoords != "-23.53,-46.62" {
+		t.Errorf("expected the WeatherAPI coordinates, got %q", mockOpenMeteoLastCoords)
+	}
+}
+
+func TestWeatherHandler_ConsensusFallsBackToSingleProvider(t *testing.T) {
+	testCases := []struct {
+		name             string
+		weatherAPIStatus int
+		weatherAPIBody   string
+		openMeteoStatus  int
+		openMeteoBody    string
+		expectedTempC    float64
+		failedProvider   string
+		failedMessage    string
+	}{
+		{
+			name:             "open-meteo fails",
+			weatherAPIStatus: http.StatusOK, weatherAPIBody: `{"current": {"temp_c": 25.0}}`,
+			openMeteoStatus: http.StatusBadRequest, openMeteoBody: `{"error": true, "reason": "Latitude must be in range of -90 to 90"}`,
+			expectedTempC: 25, failedProvider: providerOpenMeteo, failedMessage: errorProviderUnavailable,
+		},
+		{
+			name:             "weatherapi fails",
+			weatherAPIStatus: http.StatusOK, weatherAPIBody: `not json`,
+			openMeteoStatus: http.StatusOK, openMeteoBody: `{"current": {"temperature_2m": 23.4}}`,
+			expectedTempC: 23.4, failedProvider: providerWeatherAPI, failedMessage: errorProviderUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+			mockBrasilAPIStatusCode = http.StatusOK
+			mockBrasilAPIResponse = `{"location": {"coordinates": {"latitude": "-23.55", "longitude": "-46.63"}}}`
+			mockWeatherAPIStatusCode = tc.weatherAPIStatus
+			mockWeatherAPIResponse = tc.weatherAPIBody
+			mockOpenMeteoStatusCode = tc.openMeteoStatus
+			mockOpenMeteoResponse = tc.openMeteoBody
+
+			rr, response := consensusRequest(t)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if response.TempC != tc.expectedTempC {
+				t.Errorf("expected the single provider value %v, got %v", tc.expectedTempC, response.TempC)
+			}
+			failed := providerTemp(t, response, tc.failedProvider)
+			if failed.TempC != nil || failed.Error != tc.failedMessage {
+				t.Errorf("expected %s to be reported as failed with %q, got %+v", tc.failedProvider, tc.failedMessage, failed)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_ConsensusBothProvidersFail(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"location": {"coordinates": {"latitude": "-23.55", "longitude": "-46.63"}}}`
+	mockWeatherAPIStatusCode = http.StatusServiceUnavailable
+	mockWeatherAPIResponse = `{"error": {"code": 9999, "message": "Internal application error."}}`
+	mockOpenMeteoStatusCode = http.StatusServiceUnavailable
+	mockOpenMeteoResponse = `{"error": true, "reason": "unavailable"}`
+
+	rr, _ := consensusRequest(t)
+	if rr.Code == http.StatusOK {
+		t.Fatalf("expected an error status when both providers fail, got %v", rr.Code)
+	}
+}
+
+func TestWeatherHandler_WithoutConsensusSkipsOpenMeteo(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}, "location": {"lat": -23.53, "lon": -46.62}}`
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.Consensus != nil || mockOpenMeteoCalls.Load() != 0 {
+		t.Errorf("expected no Open-Meteo call without ?consensus=true, got %d calls", mockOpenMeteoCalls.Load())
+	}
+}
+
+func TestGetOpenMeteoTemperature_CustomClient(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client := cannedClient(http.StatusOK, `{"current": {"temperature_2m": 19.7}}`)
+	tempC, err := getOpenMeteoTemperature(context.Background(), client, coordinates{Lat: -25.43, Lon: -49.27})
+	if err != nil || tempC != 19.7 {
+		t.Errorf("getOpenMeteoTemperature() = %v, %v; want 19.7", tempC, err)
+	}
+}
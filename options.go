@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Campos opcionais que podem ser solicitados via ?fields=
+const (
+	fieldHumidity  = "humidity"
+	fieldWind      = "wind"
+	fieldFeelsLike = "feelslike"
+	fieldCondition = "condition"
+)
+
+var supportedFields = map[string]bool{
+	fieldHumidity:  true,
+	fieldWind:      true,
+	fieldFeelsLike: true,
+	fieldCondition: true,
+}
+
+// Escalas de temperatura adicionais que podem ser solicitadas via ?scales=
+const (
+	scaleRankine = "rankine"
+	scaleReaumur = "reaumur"
+	scaleNewton  = "newton"
+	scaleAll     = "all" // Todas as escalas adicionais
+)
+
+var supportedScales = map[string]bool{
+	scaleRankine: true,
+	scaleReaumur: true,
+	scaleNewton:  true,
+}
+
+// Casas decimais das temperaturas: ?precision= aceita de 0 a maxPrecision
+const (
+	defaultPrecision = 1
+	maxPrecision     = 3
+)
+
+// Unidades aceitas em ?unit=, mapeadas para o rótulo retornado na resposta compacta
+const (
+	unitCelsius    = "C"
+	unitFahrenheit = "F"
+	unitKelvin     = "K"
+)
+
+var supportedUnits = map[string]string{
+	"c": unitCelsius,
+	"f": unitFahrenheit,
+	"k": unitKelvin,
+}
+
+var (
+	// errInvalidFields indica um valor desconhecido em ?fields=
+	errInvalidFields = errors.New(errorInvalidFields)
+	// errInvalidScales indica um valor desconhecido em ?scales=
+	errInvalidScales = errors.New(errorInvalidScales)
+	// errInvalidUnit indica um valor desconhecido em ?unit=
+	errInvalidUnit = errors.New(errorInvalidUnit)
+	// errInvalidBaseline indica um valor não numérico em ?baseline_c=
+	errInvalidBaseline = errors.New(errorInvalidBaseline)
+	// errInvalidPrecision indica um valor fora de 0..maxPrecision em ?precision=
+	errInvalidPrecision = errors.New(errorInvalidPrecision)
+)
+
+// responseOptions reúne as opções de resposta informadas na query string
+type responseOptions struct {
+	Extended bool            // ?extended=true
+	OnlyCity bool            // ?only_city=true
+	Fields   map[string]bool // ?fields=humidity,wind
+	Scales   map[string]bool // ?scales=rankine,reaumur,newton
+
+	WholeKelvin bool // ?whole_kelvin=true arredonda Kelvin para inteiro, mantendo C/F decimais
+	AirQuality  bool // ?aqi=true inclui a qualidade do ar (consome mais da cota da WeatherAPI)
+	Timing      bool // ?timing=true inclui a duração de cada chamada externa
+	Consensus   bool // ?consensus=true combina a WeatherAPI e a Open-Meteo
+
+	Unit string // ?unit=c|f|k responde apenas com essa escala; vazio mantém a resposta completa
+
+	BaselineC *float64 // ?baseline_c=20 inclui a diferença (delta_C) entre a leitura e essa referência
+
+	// ?precision=0..3 arredonda todas as temperaturas; nil mantém o padrão (Celsius original
+	// da WeatherAPI e demais escalas com 1 casa decimal)
+	Precision *uint
+}
+
+// parseResponseOptions lê e valida as opções de resposta da requisição
+func parseResponseOptions(r *http.Request) (responseOptions, error) {
+	opts := responseOptions{
+		Extended: queryBool(r, "extended"),
+		OnlyCity: queryBool(r, "only_city"),
+		Fields:   map[string]bool{},
+		Scales:   map[string]bool{},
+
+		WholeKelvin: queryBool(r, "whole_kelvin"),
+		AirQuality:  queryBool(r, "aqi"),
+		Timing:      queryBool(r, "timing"),
+		Consensus:   queryBool(r, "consensus"),
+	}
+
+	if raw := r.URL.Query().Get("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if !supportedFields[field] {
+				return responseOptions{}, errInvalidFields
+			}
+			opts.Fields[field] = true
+		}
+	}
+
+	if raw := r.URL.Query().Get("scales"); raw != "" {
+		for _, scale := range strings.Split(raw, ",") {
+			scale = strings.ToLower(strings.TrimSpace(scale))
+			if scale == scaleAll {
+				maps.Copy(opts.Scales, supportedScales)
+				continue
+			}
+			if !supportedScales[scale] {
+				return responseOptions{}, errInvalidScales
+			}
+			opts.Scales[scale] = true
+		}
+	}
+
+	if raw := r.URL.Query().Get("unit"); raw != "" {
+		unit, ok := supportedUnits[strings.ToLower(strings.TrimSpace(raw))]
+		if !ok {
+			return responseOptions{}, errInvalidUnit
+		}
+		opts.Unit = unit
+	}
+
+	if raw := r.URL.Query().Get("baseline_c"); raw != "" {
+		baseline, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || math.IsNaN(baseline) || math.IsInf(baseline, 0) {
+			return responseOptions{}, errInvalidBaseline
+		}
+		opts.BaselineC = &baseline
+	}
+
+	if raw := r.URL.Query().Get("precision"); raw != "" {
+		precision, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 8)
+		if err != nil || precision > maxPrecision {
+			return responseOptions{}, errInvalidPrecision
+		}
+		p := uint(precision)
+		opts.Precision = &p
+	}
+
+	return opts, nil
+}
+
+// precision retorna as casas decimais das escalas convertidas
+func (o responseOptions) precision() uint {
+	if o.Precision == nil {
+		return defaultPrecision
+	}
+	return *o.Precision
+}
+
+// roundCelsius arredonda o Celsius apenas quando ?precision= foi informado; por padrão o
+// valor original da WeatherAPI é mantido
+func (o responseOptions) roundCelsius(celsius float64) float64 {
+	if o.Precision == nil {
+		return celsius
+	}
+	return roundFloat(celsius, *o.Precision)
+}
+
+// queryBool interpreta um parâmetro booleano da query string (ex: ?extended=true).
+// Valores ausentes ou inválidos são tratados como false.
+func queryBool(r *http.Request, name string) bool {
+	value, err := strconv.ParseBool(r.URL.Query().Get(name))
+	return err == nil && value
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherAPIResponse_ParsesHumidityAndWind(t *testing.T) {
+	payload := `{"current": {"temp_c": 21.3, "humidity": 78, "wind_kph": 12.6}}`
+
+	var weatherResp WeatherAPIResponse
+	if err := json.Unmarshal([]byte(payload), &weatherResp); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+
+	if weatherResp.Current.Humidity == nil || *weatherResp.Current.Humidity != 78 {
+		t.Errorf("unexpected humidity: %v", weatherResp.Current.Humidity)
+	}
+	if weatherResp.Current.WindKph == nil || *weatherResp.Current.WindKph != 12.6 {
+		t.Errorf("unexpected wind_kph: %v", weatherResp.Current.WindKph)
+	}
+}
+
+func TestWeatherHandler_FieldSelection(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.3, "humidity": 78, "wind_kph": 12.6}}`
+
+	testCases := []struct {
+		query          string
+		expectHumidity bool
+		expectWind     bool
+	}{
+		{"", false, false},
+		{"?fields=humidity", true, false},
+		{"?fields=wind", false, true},
+		{"?fields=humidity,wind", true, true},
+		{"?fields=%20Wind%20,HUMIDITY", true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+
+			if humidity, present := body["humidity"]; present != tc.expectHumidity {
+				t.Errorf("humidity presence: got %v want %v", present, tc.expectHumidity)
+			} else if present && humidity.(float64) != 78 {
+				t.Errorf("humidity: got %v want 78", humidity)
+			}
+			if wind, present := body["wind_kph"]; present != tc.expectWind {
+				t.Errorf("wind_kph presence: got %v want %v", present, tc.expectWind)
+			} else if present && wind.(float64) != 12.6 {
+				t.Errorf("wind_kph: got %v want 12.6", wind)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_InvalidFields(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?fields=humidity,pressure", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorInvalidFields {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInvalidFields)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls for invalid fields, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_Unit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	testCases := []struct {
+		query        string
+		expectedTemp float64
+		expectedUnit string
+	}{
+		{"?unit=c", 25.5, "C"},
+		{"?unit=f", 77.9, "F"},
+		{"?unit=k", 298.5, "K"},
+		{"?unit=F", 77.9, "F"},
+		{"?unit=k&whole_kelvin=true", 299, "K"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if len(body) != 2 || body["temp"] != tc.expectedTemp || body["unit"] != tc.expectedUnit {
+				t.Errorf("got %v want {temp: %v, unit: %s}", body, tc.expectedTemp, tc.expectedUnit)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_InvalidUnit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?unit=rankine", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorInvalidUnit {
+		t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInvalidUnit)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected validation to fail before calling ViaCEP, got %d calls", calls)
+	}
+}
+
+func TestWeatherHandler_BaselineDelta(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.24}}`
+
+	ptr := func(v float64) *float64 { return &v }
+	testCases := []struct {
+		query         string
+		expectedDelta *float64
+	}{
+		{"", nil},
+		{"?baseline_c=20.16", ptr(1.1)}, // Arredondar o Celsius antes resultaria em 1.0
+		{"?baseline_c=25", ptr(-3.8)},
+		{"?baseline_c=-5", ptr(26.2)},
+		{"?baseline_c=21.24", ptr(0.0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+
+			var response WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			switch {
+			case tc.expectedDelta == nil && response.DeltaC != nil:
+				t.Errorf("expected no delta_C, got %v", *response.DeltaC)
+			case tc.expectedDelta != nil && (response.DeltaC == nil || *response.DeltaC != *tc.expectedDelta):
+				t.Errorf("got delta_C %v want %v", response.DeltaC, *tc.expectedDelta)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_InvalidBaseline(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for _, baseline := range []string{"abc", "20C", "NaN", "Inf"} {
+		t.Run(baseline, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000?baseline_c="+baseline, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorInvalidBaseline {
+				t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInvalidBaseline)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_Timing(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.0}}`
+	mockViaCEPDelay = 20 * time.Millisecond
+	mockWeatherAPIDelay = 30 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000?timing=true", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var body struct {
+		Timings map[string]float64 `json:"timings"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	for field, minimum := range map[string]float64{"viacep_ms": 20, "weatherapi_ms": 30} {
+		value, ok := body.Timings[field]
+		if !ok {
+			t.Errorf("missing timings.%s in %v", field, body.Timings)
+			continue
+		}
+		// Cada etapa é medida separadamente, então reflete o atraso do seu próprio mock
+		if value < minimum {
+			t.Errorf("timings.%s = %v, want at least %v", field, value, minimum)
+		}
+	}
+}
+
+func TestWeatherHandler_TimingOmittedByDefault(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if strings.Contains(rr.Body.String(), "timings") {
+		t.Errorf("expected no timings without ?timing=true, got %s", rr.Body.String())
+	}
+}
+
+func TestWeatherAPIResponse_ParsesFeelsLike(t *testing.T) {
+	payload := `{"current": {"temp_c": 30.0, "feelslike_c": 34.2}}`
+
+	var weatherResp WeatherAPIResponse
+	if err := json.Unmarshal([]byte(payload), &weatherResp); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if weatherResp.Current.FeelsLikeC == nil || *weatherResp.Current.FeelsLikeC != 34.2 {
+		t.Errorf("unexpected feelslike_c: %v", weatherResp.Current.FeelsLikeC)
+	}
+
+	expected := FeelsLikeResponse{TempC: 34.2, TempF: 93.6, TempK: 307.2}
+	if feelsLike := newFeelsLikeResponse(*weatherResp.Current.FeelsLikeC, responseOptions{}); *feelsLike != expected {
+		t.Errorf("got %+v want %+v", *feelsLike, expected)
+	}
+}
+
+func TestWeatherHandler_FeelsLike(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+
+	testCases := []struct {
+		name      string
+		query     string
+		upstream  string
+		expectObj bool
+	}{
+		{"default response unchanged", "", `{"current": {"temp_c": 30.0, "feelslike_c": 34.2}}`, false},
+		{"requested", "?fields=feelslike", `{"current": {"temp_c": 30.0, "feelslike_c": 34.2}}`, true},
+		{"requested but missing upstream", "?fields=feelslike", `{"current": {"temp_c": 30.0}}`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockWeatherAPIResponse = tc.upstream
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if tc.expectObj != (response.FeelsLike != nil) {
+				t.Fatalf("feels_like presence: got %v want %v", response.FeelsLike != nil, tc.expectObj)
+			}
+			if tc.expectObj && (response.FeelsLike.TempC != 34.2 || response.FeelsLike.TempF != 93.6 || response.FeelsLike.TempK != 307.2) {
+				t.Errorf("unexpected feels_like: %+v", *response.FeelsLike)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_Precision(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 24.456}}`
+
+	testCases := []struct {
+		query    string
+		expected WeatherResponse
+	}{
+		{"", WeatherResponse{TempC: 24.456, TempF: 76.0, TempK: 297.5}}, // Padrão: Celsius original, 1 casa nas demais
+		{"?precision=0", WeatherResponse{TempC: 24, TempF: 76, TempK: 297}},
+		{"?precision=1", WeatherResponse{TempC: 24.5, TempF: 76.0, TempK: 297.5}},
+		{"?precision=2", WeatherResponse{TempC: 24.46, TempF: 76.02, TempK: 297.46}},
+		{"?precision=3", WeatherResponse{TempC: 24.456, TempF: 76.021, TempK: 297.456}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var response WeatherResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Could not decode response body: %v", err)
+			}
+			if response.TempC != tc.expected.TempC || response.TempF != tc.expected.TempF || response.TempK != tc.expected.TempK {
+				t.Errorf("got C=%v F=%v K=%v want C=%v F=%v K=%v", response.TempC, response.TempF, response.TempK, tc.expected.TempC, tc.expected.TempF, tc.expected.TempK)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_InvalidPrecision(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for _, precision := range []string{"4", "-1", "1.5", "abc"} {
+		t.Run(precision, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000?precision="+precision, nil)
+			rr := httptest.NewRecorder()
+
+			weatherHandler(rr, req)
+
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorInvalidPrecision {
+				t.Errorf("handler returned unexpected body: got '%s' want '%s'", body, errorInvalidPrecision)
+			}
+		})
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 0 {
+		t.Errorf("expected validation to fail before calling ViaCEP, got %d calls", calls)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxPathLengthEnv = "MAX_PATH_LENGTH"
+
+	// defaultMaxPathLength cobre com folga as rotas válidas, incluindo nomes de cidade e
+	// listas de CEPs codificados na URL
+	defaultMaxPathLength = 1024
+)
+
+// maxPathLength é o maior path aceito (codificado, sem a query string); zero desativa o limite
+var maxPathLength = defaultMaxPathLength
+
+// withPathGuard rejeita, antes do roteamento, paths longos demais (414) ou com caracteres de
+// controle (400), comuns em varreduras automatizadas. Os caracteres de controle são
+// verificados no path já decodificado, pegando também sequências como %00 e %0A.
+func withPathGuard(maxLength int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxLength > 0 && len(r.URL.EscapedPath()) > maxLength {
+			slog.WarnContext(r.Context(), "Rejected oversized path", "length", len(r.URL.EscapedPath()), "max", maxLength)
+			writeError(w, r, http.StatusRequestURITooLong, errorURITooLong) // 414
+			return
+		}
+		if strings.ContainsFunc(r.URL.Path, unicode.IsControl) {
+			slog.WarnContext(r.Context(), "Rejected path with control characters", "path", r.URL.EscapedPath())
+			writeError(w, r, http.StatusBadRequest, errorInvalidPathChars) // 400
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouter_PathGuard(t *testing.T) {
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"oversized path", "/weather/" + strings.Repeat("a", defaultMaxPathLength), http.StatusRequestURITooLong, errorURITooLong},
+		{"encoded NUL", "/weather/01001000%00", http.StatusBadRequest, errorInvalidPathChars},
+		{"encoded newline", "/weather/%0Aadmin", http.StatusBadRequest, errorInvalidPathChars},
+		{"encoded DEL", "/weather/city/S%7Fo%20Paulo", http.StatusBadRequest, errorInvalidPathChars},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v", rr.Code, tc.expectedCode)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("got body '%s' want '%s'", body, tc.expectedBody)
+			}
+			if calls := mockViaCEPCalls.Load() + mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected the request to be rejected before any upstream call, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestRouter_PathGuardAllowsValidPaths(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	// Um path exatamente no limite ainda é aceito
+	maxPathLength = len("/weather/01001000")
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// Zero desativa o limite de tamanho
+	maxPathLength = 0
+	rr = httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/weather/city/"+strings.Repeat("a", 2*defaultMaxPathLength), nil))
+	if rr.Code == http.StatusRequestURITooLong {
+		t.Errorf("expected no length limit with MAX_PATH_LENGTH=0, got %v", rr.Code)
+	}
+}
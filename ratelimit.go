@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitRPSEnv    = "RATE_LIMIT_RPS"
+	rateLimitBurstEnv  = "RATE_LIMIT_BURST"
+	rateLimitRoutesEnv = "RATE_LIMIT_ROUTES"
+
+	// maxTrackedClients dispara a limpeza de buckets ociosos, evitando crescimento ilimitado do mapa
+	maxTrackedClients = 10000
+)
+
+var (
+	// clientRateLimiter é o limitador por IP usado pelo roteador; nil desativa o controle
+	clientRateLimiter *rateLimiter
+
+	// routeRateLimiters são limitadores por IP dedicados a rotas específicas (ex: lotes,
+	// que custam várias chamadas externas), aplicados além do limite global
+	routeRateLimiters map[string]*rateLimiter
+)
+
+// tokenBucket guarda os tokens disponíveis de um cliente
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter implementa um token bucket por chave (IP do cliente): cada chave acumula
+// até burst tokens, recarregados à taxa de rps tokens por segundo
+type rateLimiter struct {
+	mu      sync.Mutex
+	rps     float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	now     func() time.Time // Injetável para testes
+}
+
+// newRateLimiter cria um limitador com a taxa e a rajada informadas.
+// Uma rajada menor que 1 é ajustada para acomodar ao menos um segundo de requisições.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = max(1, int(math.Ceil(rps)))
+	}
+	return &rateLimiter{
+		rps:     rps,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow consome um token da chave, retornando false quando não há tokens disponíveis
+func (l *rateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxTrackedClients {
+			l.sweep(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	// Recarrega os tokens proporcionalmente ao tempo decorrido
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rps)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweep remove os buckets que já estariam cheios, pois equivalem a um cliente novo
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rps >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// retryAfter estima em quantos segundos um novo token estará disponível
+func (l *rateLimiter) retryAfter() int {
+	return max(1, int(math.Ceil(1/l.rps)))
+}
+
+// withRateLimit rejeita com 429 as requisições de clientes que excederam o limite
+func withRateLimit(limiter *rateLimiter, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if !limiter.allow(ip) {
+			slog.WarnContext(r.Context(), "Rate limit exceeded", "client_ip", ip)
+			w.Header().Set("Retry-After", strconv.Itoa(limiter.retryAfter()))
+			writeError(w, r, http.StatusTooManyRequests, errorRateLimited) // 429
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseRouteRateLimits interpreta a configuração de limites por rota no formato
+// "rota=rps[:burst],..." (ex: "/weather/batch=0.5:2,/validate=5")
+func parseRouteRateLimits(raw string) (map[string]*rateLimiter, error) {
+	var patterns []string
+	for _, route := range appRoutes() {
+		patterns = append(patterns, route.pattern)
+	}
+
+	limiters := make(map[string]*rateLimiter)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("invalid route rate limit %q: expected route=rps[:burst]", entry)
+		}
+		// Uma chave que não é padrão registrado nunca seria aplicada (ex: erro de digitação)
+		if !slices.Contains(patterns, route) {
+			return nil, fmt.Errorf("unknown route %q in rate limit, expected one of %s", route, strings.Join(patterns, ", "))
+		}
+
+		rawRPS, rawBurst, hasBurst := strings.Cut(limit, ":")
+		rps, err := strconv.ParseFloat(strings.TrimSpace(rawRPS), 64)
+		if err != nil || rps <= 0 {
+			return nil, fmt.Errorf("invalid requests per second for route %s: %q", route, rawRPS)
+		}
+		burst := 0
+		if hasBurst {
+			if burst, err = strconv.Atoi(strings.TrimSpace(rawBurst)); err != nil || burst < 1 {
+				return nil, fmt.Errorf("invalid burst for route %s: %q", route, rawBurst)
+			}
+		}
+
+		limiters[route] = newRateLimiter(rps, burst)
+	}
+	return limiters, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouter_RateLimitExceeded(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	clientRateLimiter = newRateLimiter(1, 2)
+	router := newRouter()
+
+	doRequest := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A rajada permite 2 requisições; a terceira é bloqueada
+	for i := 0; i < 2; i++ {
+		if rr := doRequest("10.0.0.1:1234", ""); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+
+	rr := doRequest("10.0.0.1:5678", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on 429")
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != errorRateLimited {
+		t.Errorf("got body %q want %q", body, errorRateLimited)
+	}
+
+	// Outro cliente tem seu próprio bucket
+	if rr := doRequest("10.0.0.2:1234", ""); rr.Code != http.StatusOK {
+		t.Errorf("other client: got status %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// Atrás de um proxy confiável, o limite é aplicado ao cliente original do X-Forwarded-For
+	trustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	for i := 0; i < 2; i++ {
+		doRequest("10.0.0.1:9999", "203.0.113.7, 10.0.0.1")
+	}
+	if rr := doRequest("10.0.0.3:1234", "203.0.113.7"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("forwarded client: got status %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRouter_RateLimitExceededLocalized(t *testing.T) {
+	setup()
+	defer teardown()
+
+	clientRateLimiter = newRateLimiter(0.001, 1)
+	router := newRouter()
+
+	doRequest := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/version", nil)
+		req.Header.Set("Accept-Language", "pt-BR")
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	doRequest("")
+	rr := doRequest("")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	expected := messageCatalog[languagePortuguese][errorRateLimited]
+	if body := strings.TrimSpace(rr.Body.String()); body != expected {
+		t.Errorf("got body %q want %q", body, expected)
+	}
+	if language := rr.Header().Get("Content-Language"); language != languagePortuguese {
+		t.Errorf("got Content-Language %q want %q", language, languagePortuguese)
+	}
+
+	// No modo XML o erro localizado vem no mesmo envelope dos demais
+	rr = doRequest("application/xml")
+	if body := rr.Body.String(); rr.Code != http.StatusTooManyRequests || !strings.Contains(body, "<message>"+expected+"</message>") {
+		t.Errorf("unexpected XML error: %d %s", rr.Code, body)
+	}
+}
+
+func TestRouter_RouteRateLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	limiters, err := parseRouteRateLimits("/weather/batch=0.1:2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	routeRateLimiters = limiters
+	router := newRouter()
+
+	doRequest := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := doRequest(http.MethodPost, "/weather/batch", `["01001000"]`); rr.Code != http.StatusOK {
+			t.Fatalf("batch request %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+	rr := doRequest(http.MethodPost, "/weather/batch", `["01001000"]`)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("batch request past the limit: got status %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "10" {
+		t.Errorf("expected Retry-After from the route limit, got %q", retryAfter)
+	}
+
+	// Consultas individuais não são afetadas pelo limite do lote
+	for i := 0; i < 5; i++ {
+		if rr := doRequest(http.MethodGet, "/weather/01001000", ""); rr.Code != http.StatusOK {
+			t.Errorf("single lookup %d: got status %v want %v", i+1, rr.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestParseRouteRateLimits(t *testing.T) {
+	limiters, err := parseRouteRateLimits(" /weather/batch=0.5:3 , /validate=4 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch := limiters["/weather/batch"]; batch == nil || batch.rps != 0.5 || batch.burst != 3 {
+		t.Errorf("unexpected batch limiter: %+v", batch)
+	}
+	if validate := limiters["/validate"]; validate == nil || validate.rps != 4 || validate.burst != 4 {
+		t.Errorf("unexpected validate limiter: %+v", validate)
+	}
+
+	for _, raw := range []string{"/weather/batch", "batch=1", "/validate=0", "/validate=abc", "/validate=1:0",
+		"/weather/bacth=1", "/forecast/{cep}/hourly=1", "GET /validate=1", "/validate/=1"} {
+		if _, err := parseRouteRateLimits(raw); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(2, 1)
+	limiter.now = func() time.Time { return now }
+
+	if !limiter.allow("client") {
+		t.Fatal("first request should be allowed")
+	}
+	if limiter.allow("client") {
+		t.Fatal("second immediate request should be limited")
+	}
+
+	now = now.Add(500 * time.Millisecond) // 2 tokens/s -> 1 token recarregado
+	if !limiter.allow("client") {
+		t.Error("request after refill should be allowed")
+	}
+}
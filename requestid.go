@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	requestIDHeader       = "X-Request-ID"
+	maxRequestIDLength    = 128
+	logRequestMetadataEnv = "LOG_REQUEST_METADATA"
+)
+
+// logRequestMetadata inclui método, path, IP e user agent nas linhas de log de erro
+var logRequestMetadata = true
+
+// requestLogContext guarda os dados da requisição usados pelo logger com escopo de requisição
+type requestLogContext struct {
+	RequestID string
+	Method    string
+	Path      string
+	ClientIP  string
+	UserAgent string
+}
+
+type requestLogContextKey struct{}
+
+// withRequestID garante que toda requisição tenha um ID de correlação: reaproveita o
+// cabeçalho X-Request-ID recebido (se válido) ou gera um UUID, devolvendo-o na resposta.
+// Também registra no contexto os metadados usados nos logs da requisição.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestLogContextKey{}, &requestLogContext{
+			RequestID: requestID,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			ClientIP:  clientIP(r),
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestIDFromContext retorna o ID da requisição, ou "" quando ausente
+func requestIDFromContext(ctx context.Context) string {
+	if info, ok := ctx.Value(requestLogContextKey{}).(*requestLogContext); ok {
+		return info.RequestID
+	}
+	return ""
+}
+
+// newRequestID gera um UUID versão 4 aleatório
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand não deve falhar em plataformas suportadas
+		slog.Error("Failed to generate request ID", "error", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Versão 4
+	b[8] = (b[8] & 0x3f) | 0x80 // Variante RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// isValidRequestID aceita IDs recebidos apenas se forem curtos e compostos de caracteres
+// seguros, evitando injeção de conteúdo arbitrário nos logs e cabeçalhos
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		isAlphanumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphanumeric && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRouter_RequestIDHeader(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	t.Run("generated when absent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		rr := httptest.NewRecorder()
+
+		newRouter().ServeHTTP(rr, req)
+
+		if requestID := rr.Header().Get(requestIDHeader); !uuidRegex.MatchString(requestID) {
+			t.Errorf("expected a generated UUID in %s, got %q", requestIDHeader, requestID)
+		}
+	})
+
+	t.Run("incoming header is reused", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		req.Header.Set(requestIDHeader, "abc-123")
+		rr := httptest.NewRecorder()
+
+		newRouter().ServeHTTP(rr, req)
+
+		if requestID := rr.Header().Get(requestIDHeader); requestID != "abc-123" {
+			t.Errorf("expected incoming request ID to be echoed, got %q", requestID)
+		}
+	})
+
+	t.Run("unsafe incoming header is replaced", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		req.Header.Set(requestIDHeader, "bad id\twith spaces")
+		rr := httptest.NewRecorder()
+
+		newRouter().ServeHTTP(rr, req)
+
+		if requestID := rr.Header().Get(requestIDHeader); !uuidRegex.MatchString(requestID) {
+			t.Errorf("expected unsafe request ID to be replaced by a UUID, got %q", requestID)
+		}
+	})
+}
+
+func TestRouter_RequestIDInLogs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelDebug)
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 20.0}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set(requestIDHeader, "trace-me")
+	newRouter().ServeHTTP(httptest.NewRecorder(), req)
+
+	records := logRecords(t, logs)
+	if len(records) == 0 {
+		t.Fatal("expected log records for the request")
+	}
+	for _, record := range records {
+		if record["request_id"] != "trace-me" {
+			t.Errorf("log record without request ID: %v", record)
+		}
+	}
+}
+
+func TestRouter_ErrorLogIncludesRequestMetadata(t *testing.T) {
+	setup()
+	defer teardown()
+
+	logs := captureLogs(t, slog.LevelInfo)
+
+	mockViaCEPResponse = `Internal Server Error`
+	mockViaCEPStatusCode = http.StatusInternalServerError
+
+	doRequest := func() {
+		req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+		req.RemoteAddr = "192.0.2.10:4321"
+		req.Header.Set("User-Agent", "monitoring-probe/2.0")
+		newRouter().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	doRequest()
+	record := findLogRecord(t, logs, "Error getting city from CEP")
+	expected := map[string]any{
+		"method":     "GET",
+		"path":       "/weather/01001000",
+		"client_ip":  "192.0.2.10",
+		"user_agent": "monitoring-probe/2.0",
+		"cep":        "01001000",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("error log record: expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+
+	// Com a opção desativada, o registro de erro não traz os metadados da requisição
+	logs.Reset()
+	logRequestMetadata = false
+	doRequest()
+	if record := findLogRecord(t, logs, "Error getting city from CEP"); record["user_agent"] != nil {
+		t.Errorf("expected no request metadata when disabled: %v", record)
+	}
+}
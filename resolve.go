@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ResolveResponse Struct para a resposta de /resolve/{cep}: apenas a localização do CEP
+type ResolveResponse struct {
+	XMLName xml.Name `json:"-" xml:"location"`
+	CEP     string   `json:"cep" xml:"cep"`
+	City    string   `json:"city" xml:"city"`
+	UF      string   `json:"uf" xml:"uf"`
+}
+
+// resolveRoutes roteia as requisições recebidas por resolveHandler
+var resolveRoutes = newResolveRoutes()
+
+// newResolveRoutes registra GET /resolve/{cep}; outros caminhos sob /resolve/ são um erro de rota
+func newResolveRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /resolve/{cep}", resolveCEPHandler)
+	mux.HandleFunc("GET /resolve/", malformedResolvePathHandler)
+	return mux
+}
+
+// malformedResolvePathHandler responde às rotas sob /resolve/ que não correspondem a /resolve/{cep}
+func malformedResolvePathHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusBadRequest, errorMalformedResolve) // 400
+}
+
+// resolveHandler atende as rotas sob /resolve/
+func resolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodHead {
+		w = headResponseWriter{w}
+	}
+	resolveRoutes.ServeHTTP(w, r)
+}
+
+// resolveCEPHandler atende GET /resolve/{cep}: valida o CEP e o resolve para cidade e UF
+// (pelo cache, pela base local ou pelo ViaCEP), sem consultar a WeatherAPI
+func resolveCEPHandler(w http.ResponseWriter, r *http.Request) {
+	cep := r.PathValue("cep")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("cep", cep))
+
+	if !isValidCEP(cep) {
+		writeError(w, r, http.StatusUnprocessableEntity, errorInvalidZipcode) // 422
+		return
+	}
+
+	ctx, cancel := upstreamContext(r)
+	defer cancel()
+
+	location, cacheHit, err := lookupCEP(ctx, defaultClients, cep, false)
+	w.Header().Set(cacheHeader, cacheStatus(cacheHit))
+	if err != nil {
+		status, message := upstreamErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "Error getting city from CEP", "cep", cep, "status", status, "error", err)
+		}
+		writeError(w, r, status, message)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, ResolveResponse{CEP: cep, City: location.City, UF: location.UF})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/resolve/01001000", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v (body %s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var response ResolveResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	expected := ResolveResponse{CEP: "01001000", City: "São Paulo", UF: "SP"}
+	if response != expected {
+		t.Errorf("got %+v want %+v", response, expected)
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 0 {
+		t.Errorf("expected no WeatherAPI calls, got %d", calls)
+	}
+}
+
+func TestResolveHandler_Errors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		viaCEP       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"invalid format", http.MethodGet, "/resolve/123", "", http.StatusUnprocessableEntity, errorInvalidZipcode},
+		{"not found", http.MethodGet, "/resolve/99999999", `{"erro": true}`, http.StatusNotFound, errorCannotFindZip},
+		{"extra segment", http.MethodGet, "/resolve/01001000/extra", "", http.StatusBadRequest, errorMalformedResolve},
+		{"missing cep", http.MethodGet, "/resolve/", "", http.StatusBadRequest, errorMalformedResolve},
+		{"wrong method", http.MethodPost, "/resolve/01001000", "", http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = tc.viaCEP
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Errorf("got status %v want %v", rr.Code, tc.expectedCode)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != tc.expectedBody {
+				t.Errorf("got body '%s' want '%s'", body, tc.expectedBody)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected no WeatherAPI calls, got %d", calls)
+			}
+		})
+	}
+}
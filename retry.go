@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+const (
+	httpMaxRetriesEnv     = "HTTP_MAX_RETRIES"
+	httpRetryBaseDelayEnv = "HTTP_RETRY_BASE_DELAY"
+	httpRetryJitterEnv    = "HTTP_RETRY_JITTER"
+
+	defaultHTTPMaxRetries     = 1
+	defaultHTTPRetryBaseDelay = 200 * time.Millisecond
+
+	// maxRetryBackoff limita o crescimento exponencial da espera entre tentativas
+	maxRetryBackoff = 5 * time.Second
+)
+
+// errUpstreamServerError indica uma resposta 5xx do provedor, que pode passar numa nova tentativa
+var errUpstreamServerError = errors.New("upstream server error")
+
+// retryPolicy define quantas vezes e com que espera uma chamada à WeatherAPI é repetida.
+// A espera dobra a cada tentativa (BaseDelay, 2×BaseDelay, ...), até maxRetryBackoff; com
+// Jitter, é sorteada entre a metade e o valor cheio, para que instâncias diferentes não
+// repitam as chamadas em sincronia.
+type retryPolicy struct {
+	MaxRetries int // Novas tentativas após a primeira; 0 desativa as repetições (inclusive após 429)
+	BaseDelay  time.Duration
+	Jitter     bool
+}
+
+// httpRetryPolicy é a política usada nas chamadas à WeatherAPI, configurada no main
+var httpRetryPolicy = retryPolicy{
+	MaxRetries: defaultHTTPMaxRetries,
+	BaseDelay:  defaultHTTPRetryBaseDelay,
+	Jitter:     true,
+}
+
+// loadRetryPolicy lê a política de novas tentativas das variáveis de ambiente
+func loadRetryPolicy() retryPolicy {
+	return retryPolicy{
+		MaxRetries: max(0, envInt(httpMaxRetriesEnv, defaultHTTPMaxRetries)),
+		BaseDelay:  envDuration(httpRetryBaseDelayEnv, defaultHTTPRetryBaseDelay),
+		Jitter:     envBool(httpRetryJitterEnv, true),
+	}
+}
+
+// backoff calcula a espera antes da nova tentativa de número retry (a partir de 0)
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < retry && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryBackoff)
+	if p.Jitter && delay > 1 {
+		half := delay / 2
+		delay = half + rand.N(delay-half+1)
+	}
+	return delay
+}
+
+// isTransient informa se a falha pode passar numa nova tentativa: erros de rede, o prazo da
+// própria tentativa (WEATHERAPI_TIMEOUT) e respostas 5xx. Com o contexto da requisição já
+// encerrado, nada é repetido.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, errUpstreamServerError) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sleepContext espera pelo tempo informado, retornando antes (com o erro do contexto) se a
+// requisição for cancelada ou o seu prazo terminar
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
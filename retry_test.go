@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadRetryPolicy(t *testing.T) {
+	t.Setenv(httpMaxRetriesEnv, "3")
+	t.Setenv(httpRetryBaseDelayEnv, "50ms")
+	t.Setenv(httpRetryJitterEnv, "false")
+
+	expected := retryPolicy{MaxRetries: 3, BaseDelay: 50 * time.Millisecond, Jitter: false}
+	if policy := loadRetryPolicy(); policy != expected {
+		t.Errorf("got %+v want %+v", policy, expected)
+	}
+
+	t.Setenv(httpMaxRetriesEnv, "-2")
+	if policy := loadRetryPolicy(); policy.MaxRetries != 0 {
+		t.Errorf("expected a negative value to disable retries, got %d", policy.MaxRetries)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := retryPolicy{BaseDelay: 100 * time.Millisecond}
+	for retry, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if delay := policy.backoff(retry); delay != expected {
+			t.Errorf("retry %d: got %s want %s", retry, delay, expected)
+		}
+	}
+	if delay := policy.backoff(30); delay != maxRetryBackoff {
+		t.Errorf("expected the backoff to be capped at %s, got %s", maxRetryBackoff, delay)
+	}
+
+	policy.Jitter = true
+	for i := 0; i < 100; i++ {
+		if delay := policy.backoff(1); delay < 100*time.Millisecond || delay > 200*time.Millisecond {
+			t.Fatalf("jittered delay %s outside [100ms, 200ms]", delay)
+		}
+	}
+}
+
+func TestCallWeatherAPI_RetriesTransientFailures(t *testing.T) {
+	httpRetryPolicy = retryPolicy{MaxRetries: 2}
+	defer func() { httpRetryPolicy = retryPolicy{} }()
+
+	var calls atomic.Int32
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch calls.Add(1) {
+		case 1:
+			return nil, errors.New("connection reset by peer")
+		case 2:
+			return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader("bad gateway")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"current": {"temp_c": 22.0}}`)), Request: req}, nil
+	})}
+
+	var out WeatherAPIResponse
+	if err := callWeatherAPI(context.Background(), client, "http://weatherapi.test/v1/current.json", "São Paulo", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 3 || out.Current.TempC != 22.0 {
+		t.Errorf("expected success on the third call, got %d calls and %+v", calls.Load(), out.Current)
+	}
+}
+
+func TestCallWeatherAPI_DoesNotRetryDefinitiveErrors(t *testing.T) {
+	httpRetryPolicy = retryPolicy{MaxRetries: 2}
+	defer func() { httpRetryPolicy = retryPolicy{} }()
+
+	var calls atomic.Int32
+	canned := cannedClient(http.StatusBadRequest, `{"error": {"code": 1006, "message": "No matching location found."}}`)
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return canned.Transport.RoundTrip(req)
+	})}
+
+	var out WeatherAPIResponse
+	if err := callWeatherAPI(context.Background(), client, "http://weatherapi.test/v1/current.json", "Atlantida", &out); !errors.Is(err, errCannotFindZip) {
+		t.Fatalf("got error %v want %v", err, errCannotFindZip)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a not-found answer not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestWeatherHandler_ZeroRetries(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     int
+		throttled  int32
+		retryAfter string
+	}{
+		{"server error", http.StatusInternalServerError, 0, ""},
+		{"rate limited with short retry-after", http.StatusOK, 5, "0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			t.Setenv(httpMaxRetriesEnv, "0")
+			httpRetryPolicy = loadRetryPolicy()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = `Weather API Service Unavailable`
+			mockWeatherAPIStatusCode = tc.status
+			mockWeatherAPIThrottled.Store(tc.throttled)
+			mockWeatherAPIRetryAfter = tc.retryAfter
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			if rr.Code < http.StatusInternalServerError {
+				t.Errorf("expected a failure status, got %v", rr.Code)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != 1 {
+				t.Errorf("expected retries to be disabled, got %d WeatherAPI calls", calls)
+			}
+		})
+	}
+}
+
+func TestCallWeatherAPI_CancelDuringBackoff(t *testing.T) {
+	httpRetryPolicy = retryPolicy{MaxRetries: 3, BaseDelay: 10 * time.Second}
+	defer func() { httpRetryPolicy = retryPolicy{} }()
+
+	var calls atomic.Int32
+	canned := cannedClient(http.StatusServiceUnavailable, "unavailable")
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return canned.Transport.RoundTrip(req)
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	var out WeatherAPIResponse
+	err := callWeatherAPI(ctx, client, "http://weatherapi.test/v1/current.json", "São Paulo", &out)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v want %v", err, context.Canceled)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected the backoff sleep to be interrupted promptly, took %s", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected no further attempts after cancellation, got %d calls", calls.Load())
+	}
+}
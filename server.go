@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// appRoute é uma rota registrada em newRouter
+type appRoute struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// appRoutes lista as rotas da aplicação. Os padrões também são as únicas chaves aceitas em
+// RATE_LIMIT_ROUTES.
+func appRoutes() []appRoute {
+	return []appRoute{
+		{"/weather/", weatherHandler}, // Usar /weather/ para capturar o CEP na URL
+		{"/weather/batch", batchHandler},
+		{"/forecast/", hourlyForecastHandler},
+		{"/resolve/", resolveHandler},
+		{"/validate", validateHandler},
+		{"/openapi.json", openAPIHandler},
+		{"/version", versionHandler},
+		{"/stats", statsHandler},
+	}
+}
+
+// newRouter registra as rotas da aplicação
+func newRouter() http.Handler {
+	mux := http.NewServeMux()
+	// Cada rota pode ter um limite próprio, aplicado depois do limite global
+	for _, route := range appRoutes() {
+		mux.Handle(route.pattern, withRateLimit(routeRateLimiters[route.pattern], route.handler))
+	}
+	// O access log fica dentro do withRequestID para registrar o ID da requisição; a contagem
+	// de requisições em andamento envolve todas as camadas, inclusive as recusadas por elas
+	return withRequestStats(&serverStats, withRequestID(withAccessLog(accessLogEnabled, withGzip(withPathGuard(maxPathLength, withRateLimit(clientRateLimiter, withSignature(responseHMACSecret, withBasePath(basePath, withClientTimeout(mux)))))))))
+}
+
+// weatherRoutes roteia as requisições recebidas por weatherHandler
+var weatherRoutes = newWeatherRoutes()
+
+// newWeatherRoutes registra as rotas sob /weather/ com os padrões do ServeMux (método e
+// variáveis de caminho). As rotas por cidade ficam em um ServeMux próprio, pois
+// /weather/city/{nome...} se sobrepõe a /weather/{cep}/forecast (ex: /weather/city/forecast)
+// e o ServeMux recusa padrões conflitantes.
+func newWeatherRoutes() *http.ServeMux {
+	byCEP := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		byCEP.HandleFunc(pattern, handler)
+		byCEP.HandleFunc(pattern+"/{$}", handler) // Uma barra final é tolerada (/weather/12345678/)
+	}
+	handle("GET /weather/{cep}", currentWeatherHandler)
+	handle("GET /weather/{cep}/forecast", forecastHandler)
+	handle("GET /weather/{cep}/hourly", hourlyHandler)
+	handle("GET /weather/{cep}/full", fullWeatherHandler)
+	// Segmentos a mais (ou a menos) são um erro de rota, não um CEP inexistente
+	byCEP.HandleFunc("GET /weather/", malformedPathHandler)
+
+	byCity := http.NewServeMux()
+	byCity.HandleFunc("GET /weather/city/{name...}", cityWeatherHandler)
+
+	mux := http.NewServeMux()
+	mux.Handle("/weather/city/", byCity)
+	mux.Handle("/weather/", byCEP)
+	return mux
+}
+
+// forecastRoutes roteia as requisições recebidas por hourlyForecastHandler
+var forecastRoutes = newForecastRoutes()
+
+// newForecastRoutes registra /forecast/{cep}/hourly, alias de /weather/{cep}/hourly
+func newForecastRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /forecast/{cep}/hourly", hourlyHandler)
+	mux.HandleFunc("GET /forecast/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "Usage: /forecast/{cep}/hourly")
+	})
+	return mux
+}
+
+// headResponseWriter descarta o corpo da resposta, preservando cabeçalhos e status,
+// para que requisições HEAD sigam o mesmo caminho do GET
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (h headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// listenAddress monta o endereço de escuta a partir de HOST e PORT. Sem host, o servidor
+// escuta em todas as interfaces; endereços IPv6 recebem colchetes (ex: "[::1]:8080").
+func listenAddress(host, port string) string {
+	return net.JoinHostPort(strings.TrimSpace(host), port)
+}
+
+// runServer atende requisições no listener até que ctx seja cancelado (ex: SIGTERM/SIGINT).
+// Nesse momento para de aceitar novas conexões e aguarda as requisições em andamento
+// terminarem, respeitando o shutdownTimeout.
+func runServer(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// O servidor parou sozinho, sem sinal de encerramento
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutdown signal received, draining connections", "timeout", shutdownTimeout, "in_flight", serverStats.inFlight.Load())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown server gracefully: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Server shutdown completed", "total_served", serverStats.totalServed.Load())
+	return nil
+}
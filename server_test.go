@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunServer_GracefulShutdownDrainsInFlightRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond) // Simula uma requisição lenta em andamento
+		io.WriteString(w, "done")
+	})
+	server := &http.Server{Handler: handler}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runServer(ctx, server, listener, 5*time.Second)
+	}()
+
+	type response struct {
+		body string
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/")
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{body: string(body), err: err}
+	}()
+
+	// Dispara o encerramento enquanto a requisição ainda está sendo processada
+	<-started
+	cancel()
+
+	resp := <-responses
+	if resp.err != nil {
+		t.Fatalf("in-flight request failed during shutdown: %v", resp.err)
+	}
+	if resp.body != "done" {
+		t.Errorf("unexpected body: got %q want %q", resp.body, "done")
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("runServer returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runServer did not return after shutdown")
+	}
+}
+
+func TestRunServer_ShutdownTimeoutExceeded(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release // Requisição que não termina dentro do timeout
+	})
+	server := &http.Server{Handler: handler}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- runServer(ctx, server, listener, 50*time.Millisecond)
+	}()
+
+	go http.Get("http://" + listener.Addr().String() + "/")
+	<-started
+	cancel()
+
+	if err := <-result; err == nil {
+		t.Error("expected an error when the shutdown timeout is exceeded")
+	}
+}
+
+func TestListenAddress(t *testing.T) {
+	testCases := []struct {
+		host     string
+		port     string
+		expected string
+	}{
+		{"", "8080", ":8080"}, // Sem HOST: todas as interfaces
+		{"127.0.0.1", "8080", "127.0.0.1:8080"},
+		{" 0.0.0.0 ", "9000", "0.0.0.0:9000"},
+		{"::1", "8080", "[::1]:8080"},
+		{"localhost", "8080", "localhost:8080"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if address := listenAddress(tc.host, tc.port); address != tc.expected {
+				t.Errorf("listenAddress(%q, %q) = %q, want %q", tc.host, tc.port, address, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWeatherRoutes(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+	mockForecastResponse = `{"forecast": {"forecastday": [{"date": "2025-04-21", "day": {"maxtemp_c": 27.5, "mintemp_c": 17.0}}]}}`
+
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"current weather", http.MethodGet, "/weather/01001000", http.StatusOK, `"temp_C":25`},
+		{"sub-route with trailing slash", http.MethodGet, "/weather/01001000/forecast/", http.StatusOK, `"max_temp_C":27.5`},
+		{"city named like a sub-route", http.MethodGet, "/weather/city/forecast", http.StatusOK, `"temp_C":25`},
+		{"unknown sub-route", http.MethodGet, "/weather/01001000/daily", http.StatusBadRequest, errorMalformedPath},
+		{"wrong method on sub-route", http.MethodPost, "/weather/01001000/forecast", http.StatusMethodNotAllowed, ""},
+		{"wrong method on city", http.MethodDelete, "/weather/city/Curitiba", http.StatusMethodNotAllowed, ""},
+		{"wrong method on alias", http.MethodPost, "/forecast/01001000/hourly", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("got status %v want %v (body %s)", rr.Code, tc.expectedCode, rr.Body.String())
+			}
+			if rr.Code == http.StatusMethodNotAllowed {
+				if allow := rr.Header().Get("Allow"); allow != "GET, HEAD" {
+					t.Errorf("got Allow %q want %q", allow, "GET, HEAD")
+				}
+			}
+			if !strings.Contains(rr.Body.String(), tc.expectedBody) {
+				t.Errorf("expected body to contain %q, got %s", tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	signatureHeader       = "X-Signature"
+	responseHMACSecretEnv = "RESPONSE_HMAC_SECRET"
+)
+
+// responseHMACSecret é o segredo compartilhado usado para assinar as respostas; vazio desativa
+var responseHMACSecret []byte
+
+// withSignature acrescenta o cabeçalho X-Signature com o HMAC-SHA256 (em hexadecimal) do
+// corpo da resposta, permitindo ao cliente verificar que ele não foi alterado no caminho.
+// A assinatura cobre o corpo antes de qualquer compressão.
+func withSignature(secret []byte, next http.Handler) http.Handler {
+	if len(secret) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+
+		w.Header().Set(signatureHeader, signBody(secret, bw.buf.Bytes()))
+		w.WriteHeader(bw.status)
+		w.Write(bw.buf.Bytes())
+	})
+}
+
+// signBody calcula o HMAC-SHA256 do corpo em hexadecimal
+func signBody(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
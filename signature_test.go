@@ -0,0 +1,80 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_ResponseSignature(t *testing.T) {
+	setup()
+	defer teardown()
+
+	responseHMACSecret = []byte("shared-secret")
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	mac := hmac.New(sha256.New, []byte("shared-secret"))
+	mac.Write(rr.Body.Bytes())
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if signature := rr.Header().Get(signatureHeader); signature != expected {
+		t.Errorf("signature does not match body: got %q want %q", signature, expected)
+	}
+}
+
+func TestRouter_ResponseSignatureCoversUncompressedBody(t *testing.T) {
+	setup()
+	defer teardown()
+
+	responseHMACSecret = []byte("shared-secret")
+	gzipMinSize = 0
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	reader, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("response is not valid gzip: %v", err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("could not decompress body: %v", err)
+	}
+
+	if signature := rr.Header().Get(signatureHeader); signature != signBody(responseHMACSecret, body) {
+		t.Errorf("expected signature over the decompressed body, got %q", signature)
+	}
+}
+
+func TestRouter_ResponseSignatureDisabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/123", nil)
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+
+	if signature := rr.Header().Get(signatureHeader); signature != "" {
+		t.Errorf("expected no signature without a secret, got %q", signature)
+	}
+}
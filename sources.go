@@ -0,0 +1,30 @@
+package main
+
+import "slices"
+
+// Nomes das origens listadas em "sources" (?extended=true), na ordem em que cada etapa rodou
+const (
+	sourceViaCEP      = "viacep"
+	sourceCEPDatabase = "cep-database"
+	sourceBrasilAPI   = "brasilapi"
+	sourceWeatherAPI  = providerWeatherAPI
+	sourceOpenMeteo   = providerOpenMeteo
+
+	// cacheSourceSuffix marca as origens cujo dado foi servido do cache (ex: "weatherapi-cache")
+	cacheSourceSuffix = "-cache"
+)
+
+// cachedSources devolve uma cópia das origens marcadas como servidas do cache
+func cachedSources(sources []string) []string {
+	cached := make([]string, len(sources))
+	for i, source := range sources {
+		cached[i] = source + cacheSourceSuffix
+	}
+	return cached
+}
+
+// responseSources monta a cadeia de origens da resposta: primeiro as da localização, depois
+// as da leitura de clima
+func responseSources(location cepLocation, weather *weatherReading) []string {
+	return append(slices.Clone(location.Sources), weather.Sources...)
+}
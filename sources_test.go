@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sourcesRequest consulta o caminho informado e devolve a cadeia de origens da resposta
+func sourcesRequest(t *testing.T, path string) []string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("%s: got status %v want %v (body %s)", path, rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	return response.Sources
+}
+
+func TestWeatherHandler_SourcesFreshFetchAndCacheHit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	weatherCacheTTL = time.Hour
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	fresh := sourcesRequest(t, "/weather/01001000?extended=true")
+	if want := []string{"viacep", "weatherapi"}; !slices.Equal(fresh, want) {
+		t.Errorf("fresh fetch: got sources %v want %v", fresh, want)
+	}
+
+	cached := sourcesRequest(t, "/weather/01001000?extended=true")
+	if want := []string{"viacep-cache", "weatherapi-cache"}; !slices.Equal(cached, want) {
+		t.Errorf("cache hit: got sources %v want %v", cached, want)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 1 {
+		t.Errorf("expected a single ViaCEP call, got %d", calls)
+	}
+}
+
+func TestWeatherHandler_SourcesWithCoordinatesAndConsensus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockBrasilAPIStatusCode = http.StatusOK
+	mockBrasilAPIResponse = `{"location": {"coordinates": {"latitude": "-23.55", "longitude": "-46.63"}}}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+	mockOpenMeteoResponse = `{"current": {"temperature_2m": 24.1}}`
+
+	sources := sourcesRequest(t, "/weather/01001000?extended=true&consensus=true")
+	if want := []string{"viacep", "brasilapi", "weatherapi", "open-meteo"}; !slices.Equal(sources, want) {
+		t.Errorf("got sources %v want %v", sources, want)
+	}
+}
+
+func TestWeatherHandler_SourcesLocalCEPDatabase(t *testing.T) {
+	setup()
+	defer teardown()
+
+	db, err := loadCEPDatabase(writeCEPDatabase(t, "69900000,Rio Branco,AC\n"))
+	if err != nil {
+		t.Fatalf("failed to load database: %v", err)
+	}
+	localCEPDB = db
+	mockWeatherAPIResponse = `{"current": {"temp_c": 30.0}}`
+
+	sources := sourcesRequest(t, "/weather/69900000?extended=true")
+	if want := []string{"cep-database", "weatherapi"}; !slices.Equal(sources, want) {
+		t.Errorf("got sources %v want %v", sources, want)
+	}
+}
+
+func TestWeatherHandler_SourcesOnlyInExtendedMode(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.0}}`
+
+	if sources := sourcesRequest(t, "/weather/01001000"); sources != nil {
+		t.Errorf("expected no sources outside the extended mode, got %v", sources)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// requestStats conta as requisições em andamento e as já atendidas, para acompanhar a
+// drenagem de conexões em deploys graduais
+type requestStats struct {
+	inFlight    atomic.Int64
+	totalServed atomic.Int64
+}
+
+// serverStats são os contadores do servidor, expostos em /stats
+var serverStats requestStats
+
+// StatsResponse Struct para a resposta de /stats
+type StatsResponse struct {
+	InFlight    int64 `json:"in_flight"`
+	TotalServed int64 `json:"total_served"`
+}
+
+// reset zera os contadores (usado nos testes)
+func (s *requestStats) reset() {
+	s.inFlight.Store(0)
+	s.totalServed.Store(0)
+}
+
+// snapshot lê os contadores no momento da chamada
+func (s *requestStats) snapshot() StatsResponse {
+	return StatsResponse{InFlight: s.inFlight.Load(), TotalServed: s.totalServed.Load()}
+}
+
+// withRequestStats conta a requisição como em andamento enquanto next a atende. O defer
+// garante o decremento mesmo se o handler entrar em pânico.
+func withRequestStats(stats *requestStats, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats.inFlight.Add(1)
+		defer func() {
+			stats.inFlight.Add(-1)
+			stats.totalServed.Add(1)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statsHandler informa as requisições em andamento (incluindo a própria consulta) e o total
+// atendido desde o início do processo
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)) // 405
+		return
+	}
+
+	writeUncachedJSON(r.Context(), w, http.StatusOK, serverStats.snapshot())
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWithRequestStats_ConcurrentRequests(t *testing.T) {
+	var stats requestStats
+
+	const requests = 10
+	var entered sync.WaitGroup
+	entered.Add(requests)
+	release := make(chan struct{})
+	handler := withRequestStats(&stats, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered.Done()
+		<-release
+	}))
+
+	var done sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+		}()
+	}
+
+	// Todas as requisições estão dentro do handler ao mesmo tempo
+	entered.Wait()
+	if got := stats.snapshot(); got != (StatsResponse{InFlight: requests, TotalServed: 0}) {
+		t.Errorf("while blocked: got %+v want %d in flight and none served", got, requests)
+	}
+
+	close(release)
+	done.Wait()
+	if got := stats.snapshot(); got != (StatsResponse{InFlight: 0, TotalServed: requests}) {
+		t.Errorf("after completion: got %+v want none in flight and %d served", got, requests)
+	}
+}
+
+func TestWithRequestStats_Panic(t *testing.T) {
+	var stats requestStats
+	handler := withRequestStats(&stats, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	if got := stats.inFlight.Load(); got != 0 {
+		t.Errorf("expected the counter to be decremented after a panic, got %d", got)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	router := newRouter()
+	for i := 0; i < 3; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v", rr.Code, http.StatusOK)
+	}
+	// Os contadores são ao vivo e não podem ser servidos por um cache
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("got Cache-Control %q want %q", cacheControl, "no-store")
+	}
+
+	var response StatsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	// A própria consulta a /stats está em andamento quando os contadores são lidos
+	expected := StatsResponse{InFlight: 1, TotalServed: 3}
+	if response != expected {
+		t.Errorf("got %+v want %+v", response, expected)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %v want %v", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	weatherAPIMaxRetryAfterEnv = "WEATHER_API_MAX_RETRY_AFTER"
+
+	defaultWeatherAPIMaxRetryAfter = 2 * time.Second
+
+	weatherAPIQuotaExceededCode = 2007 // Código da WeatherAPI para "API key has exceeded calls per month quota."
+	weatherAPIKeyDisabledCode   = 2008 // Código da WeatherAPI para "API key has been disabled."
+)
+
+// errUpstreamRateLimited indica que a WeatherAPI recusou a chamada por limite do plano
+var errUpstreamRateLimited = errors.New(errorUpstreamRateLimited)
+
+// weatherAPIMaxRetryAfter é a maior espera aceita antes de repetir uma chamada recusada com 429;
+// um Retry-After maior (ou ausente) devolve 503 imediatamente. Zero desativa a nova tentativa.
+var weatherAPIMaxRetryAfter = defaultWeatherAPIMaxRetryAfter
+
+// rateLimitedError é o erro de limite da WeatherAPI, com a espera sugerida no Retry-After
+type rateLimitedError struct {
+	status     int
+	code       int           // Código de erro no corpo (2007/2008), zero quando veio apenas o status 429
+	retryAfter time.Duration // Espera sugerida; só é válida quando hasRetry é verdadeiro
+	hasRetry   bool
+}
+
+func (e *rateLimitedError) Error() string {
+	if e.code != 0 {
+		return fmt.Sprintf("WeatherAPI rate limited: status %d, code %d", e.status, e.code)
+	}
+	return fmt.Sprintf("WeatherAPI rate limited: status %d", e.status)
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return errUpstreamRateLimited
+}
+
+// retryable informa se vale esperar e repetir a chamada: a espera precisa caber no limite
+// configurado e no prazo restante da requisição
+func (e *rateLimitedError) retryable(ctx context.Context) bool {
+	if !e.hasRetry || e.retryAfter > weatherAPIMaxRetryAfter || weatherAPIMaxRetryAfter <= 0 {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= e.retryAfter {
+		return false
+	}
+	return true
+}
+
+// newRateLimitedError cria o erro de limite a partir de uma resposta 429 da WeatherAPI
+func newRateLimitedError(resp *http.Response) *rateLimitedError {
+	retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return &rateLimitedError{status: resp.StatusCode, retryAfter: retryAfter, hasRetry: ok}
+}
+
+// isWeatherAPIRateLimitCode indica os códigos de erro no corpo que sinalizam cota esgotada
+// ou chave desativada. Nenhum dos dois se resolve em segundos, então não há nova tentativa.
+func isWeatherAPIRateLimitCode(code int) bool {
+	return code == weatherAPIQuotaExceededCode || code == weatherAPIKeyDisabledCode
+}
+
+// parseRetryAfter interpreta o cabeçalho Retry-After, em segundos ("5") ou como data HTTP
+// ("Wed, 21 Oct 2015 07:28:00 GMT"). Datas no passado resultam em espera zero.
+func parseRetryAfter(raw string, now time.Time) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(raw); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// callWeatherAPI executa uma chamada à WeatherAPI, repetindo-a conforme httpRetryPolicy:
+// falhas transitórias esperam o backoff exponencial, e um 429 com Retry-After curto espera
+// o tempo sugerido. Cada nova tentativa conta no limite de chamadas da requisição, e o
+// cancelamento da requisição interrompe a espera.
+func callWeatherAPI(ctx context.Context, client *http.Client, requestURL, query string, out weatherAPIPayload) error {
+	for retry := 0; ; retry++ {
+		err := callWeatherAPIOnce(ctx, client, requestURL, query, out)
+		if err == nil || retry >= httpRetryPolicy.MaxRetries {
+			return err
+		}
+
+		var delay time.Duration
+		var limited *rateLimitedError
+		switch {
+		case errors.As(err, &limited):
+			if !limited.retryable(ctx) {
+				return err
+			}
+			delay = limited.retryAfter
+			slog.WarnContext(ctx, "WeatherAPI rate limited, retrying", "query", query, "retry_after", delay)
+		case isTransient(ctx, err):
+			delay = httpRetryPolicy.backoff(retry)
+			slog.WarnContext(ctx, "WeatherAPI call failed, retrying", "query", query, "retry", retry+1, "backoff", delay, "error", err)
+		default:
+			return err
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
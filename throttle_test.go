@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 4, 21, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		raw      string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{" 5 ", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 21 Apr 2025 12:00:03 GMT", 3 * time.Second, true},
+		{"Mon, 21 Apr 2025 11:59:00 GMT", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tc.raw, now)
+			if delay != tc.expected || ok != tc.ok {
+				t.Errorf("got (%v, %v) want (%v, %v)", delay, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestWeatherHandler_RateLimitedThenSuccess(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.0}}`
+	mockWeatherAPIThrottled.Store(1)
+	mockWeatherAPIRetryAfter = "0"
+	httpRetryPolicy.MaxRetries = 1
+
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockWeatherAPICalls.Load(); calls != 2 {
+		t.Errorf("expected the rate-limited call to be retried once, got %d calls", calls)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.TempC != 21.0 {
+		t.Errorf("got temp_C %v want 21.0", response.TempC)
+	}
+}
+
+func TestWeatherHandler_RateLimitedPersistent(t *testing.T) {
+	testCases := []struct {
+		name          string
+		throttled     int32
+		retryAfter    string
+		response      string
+		status        int
+		expectedCalls int32
+	}{
+		{"still limited after retry", 5, "0", "", http.StatusOK, 2},
+		{"retry-after above the limit", 5, "60", "", http.StatusOK, 1},
+		{"no retry-after", 5, "", "", http.StatusOK, 1},
+		{"monthly quota exceeded", 0, "", `{"error": {"code": 2007, "message": "API key has exceeded calls per month quota."}}`, http.StatusForbidden, 1},
+		{"api key disabled", 0, "", `{"error": {"code": 2008, "message": "API key has been disabled."}}`, http.StatusForbidden, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockWeatherAPIResponse = tc.response
+			mockWeatherAPIStatusCode = tc.status
+			mockWeatherAPIThrottled.Store(tc.throttled)
+			mockWeatherAPIRetryAfter = tc.retryAfter
+			httpRetryPolicy.MaxRetries = 1
+
+			req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, req)
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorUpstreamRateLimited {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, errorUpstreamRateLimited)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != tc.expectedCalls {
+				t.Errorf("got %d WeatherAPI calls want %d", calls, tc.expectedCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	// Variáveis padrão do OpenTelemetry; o exportador OTLP lê as demais (cabeçalhos, timeout, etc.)
+	otlpEndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+
+	// tracerName identifica a instrumentação nos spans exportados
+	tracerName = "github.com/marmota-alpina/cep-weather-api"
+
+	// redactedQueryValue substitui a chave da API nas URLs anexadas aos spans
+	redactedQueryValue = "REDACTED"
+)
+
+// tracerProvider cria os spans da aplicação; sem endpoint OTLP configurado é um no-op
+var tracerProvider trace.TracerProvider = noop.NewTracerProvider()
+
+// tracePropagator lê o contexto de trace recebido (cabeçalhos traceparent e baggage)
+var tracePropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// initTracing configura a exportação de spans via OTLP/HTTP quando um endpoint é informado.
+// A função retornada envia os spans pendentes no encerramento.
+func initTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv(otlpEndpointEnv) == "" && os.Getenv(otlpTracesEndpointEnv) == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// O nome do serviço vem de OTEL_SERVICE_NAME (ou OTEL_RESOURCE_ATTRIBUTES)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	tracerProvider = provider
+	slog.Info("Tracing enabled", "exporter", "otlp")
+	return provider.Shutdown, nil
+}
+
+// startSpan inicia um span filho do trace presente no contexto
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracerProvider.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startServerSpan inicia o span de uma requisição recebida, continuando o trace do cliente
+func startServerSpan(r *http.Request, name string) (context.Context, trace.Span) {
+	ctx := tracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracerProvider.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		),
+	)
+}
+
+// endServerSpan registra o status da resposta; apenas erros 5xx marcam o span como falha
+func endServerSpan(span trace.Span, status int) {
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+
+// endSpan registra o erro (se houver) e encerra o span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		err = redactSpanError(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// redactSpanError remove a chave da API das URLs contidas no erro; erros do net/http
+// (*url.Error) trazem a URL completa da requisição, incluindo o parâmetro key
+func redactSpanError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), urlErr.URL, redactURL(urlErr.URL)))
+}
+
+// redactURL mascara o parâmetro key da URL; uma URL ilegível é omitida por inteiro
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedQueryValue
+	}
+	query := parsed.Query()
+	if query.Has("key") {
+		query.Set("key", redactedQueryValue)
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWeatherHandler_TracingSpans(t *testing.T) {
+	setup()
+	defer teardown()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 25.5}}`
+
+	// O cliente envia o contexto de trace, que deve ser continuado pela API
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/weather/01001000", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+
+	weatherHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d: %v", len(spans), spans)
+	}
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+		if got := span.SpanContext.TraceID().String(); got != traceID {
+			t.Errorf("span %s: got trace ID %s want %s", span.Name, got, traceID)
+		}
+	}
+
+	root, ok := byName["weatherHandler"]
+	if !ok {
+		t.Fatalf("missing weatherHandler span in %v", spans)
+	}
+	for _, name := range []string{"getCityFromCEP", "getWeatherForCity"} {
+		span, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s span in %v", name, spans)
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("span %s is not a child of weatherHandler", name)
+		}
+	}
+
+	expectAttribute(t, root, attribute.String("cep", "01001000"))
+	expectAttribute(t, root, attribute.String("city", "São Paulo"))
+	expectAttribute(t, root, attribute.Int("http.response.status_code", http.StatusOK))
+	expectAttribute(t, byName["getCityFromCEP"], attribute.String("cep", "01001000"))
+	expectAttribute(t, byName["getCityFromCEP"], attribute.String("city", "São Paulo"))
+	expectAttribute(t, byName["getWeatherForCity"], attribute.String("city", "São Paulo"))
+}
+
+func TestWeatherHandler_TracingRecordsErrors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	mockViaCEPStatusCode = http.StatusInternalServerError
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans without the WeatherAPI call, got %d: %v", len(spans), spans)
+	}
+	for _, span := range spans {
+		if span.Status.Code.String() != "Error" {
+			t.Errorf("span %s: expected error status, got %v", span.Name, span.Status)
+		}
+	}
+}
+
+func TestWeatherHandler_TracingRedactsAPIKey(t *testing.T) {
+	setup()
+	defer teardown()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	mockViaCEPResponse = `{"localidade": "São Paulo", "uf": "SP"}`
+
+	// Uma porta sem servidor faz o net/http devolver um *url.Error com a URL completa
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	originalURL := weatherAPIURL
+	weatherAPIURL = "http://" + listener.Addr().String()
+	defer func() { weatherAPIURL = originalURL }()
+	listener.Close()
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	recorded := false
+	for _, span := range exporter.GetSpans() {
+		texts := []string{span.Status.Description}
+		for _, attr := range span.Attributes {
+			texts = append(texts, attr.Value.Emit())
+		}
+		for _, event := range span.Events {
+			texts = append(texts, event.Name)
+			for _, attr := range event.Attributes {
+				texts = append(texts, attr.Value.Emit())
+			}
+		}
+		for _, text := range texts {
+			if strings.Contains(text, weatherAPIKey) {
+				t.Errorf("span %s exported the API key: %q", span.Name, text)
+			}
+			if strings.Contains(text, "key="+redactedQueryValue) {
+				recorded = true
+			}
+		}
+	}
+	if !recorded {
+		t.Errorf("expected the redacted WeatherAPI error in the spans, got %v", exporter.GetSpans())
+	}
+}
+
+// expectAttribute verifica se o span registrou o atributo com o valor esperado
+func expectAttribute(t *testing.T, span tracetest.SpanStub, expected attribute.KeyValue) {
+	t.Helper()
+	for _, attr := range span.Attributes {
+		if attr.Key == expected.Key {
+			if attr.Value != expected.Value {
+				t.Errorf("span %s: attribute %s got %v want %v", span.Name, attr.Key, attr.Value.Emit(), expected.Value.Emit())
+			}
+			return
+		}
+	}
+	t.Errorf("span %s: missing attribute %s", span.Name, expected.Key)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	minTLSVersionEnv     = "MIN_TLS_VERSION"
+	defaultMinTLSVersion = tls.VersionTLS12
+)
+
+// tlsVersions mapeia os valores aceitos em MIN_TLS_VERSION. Versões anteriores à 1.2 não
+// são aceitas: a ideia da configuração é só endurecer o mínimo.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion converte o valor de MIN_TLS_VERSION (ex: "1.3" ou "TLS1.3"); vazio usa o padrão
+func parseTLSVersion(raw string) (uint16, error) {
+	raw = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(raw)), "TLS")
+	if raw == "" {
+		return defaultMinTLSVersion, nil
+	}
+	version, ok := tlsVersions[raw]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q (use 1.2 or 1.3)", raw)
+	}
+	return version, nil
+}
+
+// loadMinTLSVersion lê MIN_TLS_VERSION; um valor inválido gera aviso e mantém o TLS 1.2
+func loadMinTLSVersion() uint16 {
+	version, err := parseTLSVersion(os.Getenv(minTLSVersionEnv))
+	if err != nil {
+		slog.Warn("Invalid minimum TLS version, using default", "env", minTLSVersionEnv, "error", err, "default", tls.VersionName(defaultMinTLSVersion))
+		return defaultMinTLSVersion
+	}
+	return version
+}
+
+// newUpstreamTransport cria o transporte das chamadas externas a partir do padrão do Go
+// (proxy do ambiente, keep-alive, HTTP/2), recusando negociar TLS abaixo de minVersion
+func newUpstreamTransport(minVersion uint16) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: minVersion}
+	return transport
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+const (
+	validateMaxSizeEnv     = "VALIDATE_MAX_SIZE"
+	defaultValidateMaxSize = 1000
+)
+
+// validateMaxSize limita a quantidade de CEPs aceitos em uma única validação
+var validateMaxSize = defaultValidateMaxSize
+
+// ValidationResult Struct para o resultado da validação de um CEP
+type ValidationResult struct {
+	Input string `json:"input" xml:"input"`
+	CEP   string `json:"cep" xml:"cep"` // CEP normalizado (somente dígitos)
+	Valid bool   `json:"valid" xml:"valid"`
+}
+
+// ValidationResults lista de resultados da validação; em XML é envolvida por <results>
+type ValidationResults []ValidationResult
+
+// MarshalXML serializa a lista como <results><result>...</result></results>
+func (v ValidationResults) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "results"
+	return e.EncodeElement(struct {
+		Results []ValidationResult `xml:"result"`
+	}{v}, start)
+}
+
+// validateHandler atende POST /validate, verificando apenas o formato de cada CEP
+// (após normalização), sem nenhuma chamada ao ViaCEP ou à WeatherAPI
+func validateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)) // 405
+		return
+	}
+
+	var ceps []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&ceps); err != nil {
+		writeError(w, r, http.StatusBadRequest, errorInvalidBatchBody) // 400
+		return
+	}
+	if len(ceps) == 0 || len(ceps) > validateMaxSize {
+		writeError(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("request must contain between 1 and %d CEPs", validateMaxSize)) // 422
+		return
+	}
+
+	results := make(ValidationResults, len(ceps))
+	for i, input := range ceps {
+		cep := normalizeCEP(input)
+		results[i] = ValidationResult{Input: input, CEP: cep, Valid: isValidCEP(cep)}
+	}
+
+	writeResponse(w, r, http.StatusOK, results)
+}
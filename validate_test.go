@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateHandler_MixedInput(t *testing.T) {
+	setup()
+	defer teardown()
+
+	body := `["01001000", "01001-000", " 20.040-002 ", "123", "abcdefgh", "123456789", ""]`
+	req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var results []ValidationResult
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+
+	expected := []ValidationResult{
+		{Input: "01001000", CEP: "01001000", Valid: true},
+		{Input: "01001-000", CEP: "01001000", Valid: true},
+		{Input: " 20.040-002 ", CEP: "20040002", Valid: true},
+		{Input: "123", CEP: "123", Valid: false},
+		{Input: "abcdefgh", CEP: "abcdefgh", Valid: false},
+		{Input: "123456789", CEP: "123456789", Valid: false},
+		{Input: "", CEP: "", Valid: false},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("result %d: got %+v want %+v", i, results[i], expected[i])
+		}
+	}
+
+	if calls := mockViaCEPCalls.Load() + mockWeatherAPICalls.Load(); calls != 0 {
+		t.Errorf("expected no upstream calls, got %d", calls)
+	}
+}
+
+func TestValidateHandler_InvalidRequests(t *testing.T) {
+	setup()
+	defer teardown()
+
+	validateMaxSize = 2
+
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"not a JSON array", http.MethodPost, `"01001000"`, http.StatusBadRequest},
+		{"too many CEPs", http.MethodPost, `["1", "2", "3"]`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/validate", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			newRouter().ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "net/http"
+
+// Informações de build, injetadas via -ldflags no momento da compilação. Ex:
+//
+//	go build -ldflags="-X main.version=1.2.0 -X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	gitCommit = "unknown"
+	buildTime = "unknown"
+)
+
+// VersionResponse Struct para a resposta de /version
+type VersionResponse struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// versionHandler informa qual build está em execução, para verificação de deploys
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, r, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed)) // 405
+		return
+	}
+
+	writeUncachedJSON(r.Context(), w, http.StatusOK, VersionResponse{
+		Version:   version,
+		GitCommit: gitCommit,
+		BuildTime: buildTime,
+	})
+}
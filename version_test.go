@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_Version(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	rr := httptest.NewRecorder()
+
+	newRouter().ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("got Cache-Control %q want %q", cacheControl, "no-store")
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	// Sem -ldflags, os valores padrão são retornados
+	expected := map[string]string{"version": "dev", "git_commit": "unknown", "build_time": "unknown"}
+	for field, value := range expected {
+		if body[field] != value {
+			t.Errorf("got %s %q want %q", field, body[field], value)
+		}
+	}
+	if len(body) != len(expected) {
+		t.Errorf("unexpected fields in response: %v", body)
+	}
+}
+
+func TestVersionHandler_MethodNotAllowed(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/version", nil)
+	rr := httptest.NewRecorder()
+
+	versionHandler(rr, req)
+
+	if status := rr.Code; status != http.StatusMethodNotAllowed {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// errViaCEPRateLimited indica que o ViaCEP recusou a chamada com 429 e as novas tentativas não resolveram
+var errViaCEPRateLimited = errors.New(errorViaCEPRateLimited)
+
+// viaCEPRateLimitedError é a resposta 429 do ViaCEP, com a espera sugerida no Retry-After
+type viaCEPRateLimitedError struct {
+	retryAfter time.Duration // Espera sugerida; só é válida quando hasRetry é verdadeiro
+	hasRetry   bool
+}
+
+func (e *viaCEPRateLimitedError) Error() string {
+	return "ViaCEP rate limited: status 429"
+}
+
+func (e *viaCEPRateLimitedError) Unwrap() error {
+	return errViaCEPRateLimited
+}
+
+// newViaCEPRateLimitedError cria o erro de limite a partir de uma resposta 429 do ViaCEP
+func newViaCEPRateLimitedError(resp *http.Response) *viaCEPRateLimitedError {
+	retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return &viaCEPRateLimitedError{retryAfter: retryAfter, hasRetry: ok}
+}
+
+// retryDelay calcula a espera antes da nova tentativa de número retry: o Retry-After, quando
+// informado, ou o backoff de httpRetryPolicy. Esperas acima de maxRetryBackoff, ou que não
+// cabem no prazo restante da requisição, não valem a pena.
+func (e *viaCEPRateLimitedError) retryDelay(ctx context.Context, retry int) (time.Duration, bool) {
+	delay := httpRetryPolicy.backoff(retry)
+	if e.hasRetry {
+		delay = e.retryAfter
+	}
+	if delay > maxRetryBackoff {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// callViaCEP consulta o ViaCEP, repetindo a chamada recusada com 429 até httpRetryPolicy.MaxRetries
+// vezes. Persistindo o limite, o erro é mapeado para 503. Demais falhas não são repetidas.
+func callViaCEP(ctx context.Context, client *http.Client, cep string) (ViaCEPResponse, error) {
+	for retry := 0; ; retry++ {
+		viaCEPResp, err := callViaCEPOnce(ctx, client, cep)
+		var limited *viaCEPRateLimitedError
+		if err == nil || retry >= httpRetryPolicy.MaxRetries || !errors.As(err, &limited) {
+			return viaCEPResp, err
+		}
+
+		delay, ok := limited.retryDelay(ctx, retry)
+		if !ok {
+			return viaCEPResp, err
+		}
+		slog.WarnContext(ctx, "ViaCEP rate limited, retrying", "cep", cep, "retry", retry+1, "delay", delay)
+		if err := sleepContext(ctx, delay); err != nil {
+			return ViaCEPResponse{}, err
+		}
+	}
+}
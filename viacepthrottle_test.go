@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherHandler_ViaCEPRateLimitedThenSuccess(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.0}}`
+	mockViaCEPThrottled.Store(1)
+	mockViaCEPRetryAfter = "0"
+	httpRetryPolicy.MaxRetries = 1
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 2 {
+		t.Errorf("expected the rate-limited ViaCEP call to be retried once, got %d calls", calls)
+	}
+	var response WeatherResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Could not decode response body: %v", err)
+	}
+	if response.TempC != 21.0 {
+		t.Errorf("unexpected temperature: got %v want 21", response.TempC)
+	}
+}
+
+func TestWeatherHandler_ViaCEPRateLimitedWithoutRetryAfterUsesBackoff(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockWeatherAPIResponse = `{"current": {"temp_c": 21.0}}`
+	mockViaCEPThrottled.Store(2)
+	httpRetryPolicy = retryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}
+
+	rr := httptest.NewRecorder()
+	weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if calls := mockViaCEPCalls.Load(); calls != 3 {
+		t.Errorf("expected two retries, got %d calls", calls)
+	}
+}
+
+func TestWeatherHandler_ViaCEPPersistentlyRateLimited(t *testing.T) {
+	testCases := []struct {
+		name          string
+		maxRetries    int
+		retryAfter    string
+		expectedCalls int32
+	}{
+		{"retries exhausted", 2, "0", 3},
+		{"retry-after too long", 2, "60", 1},
+		{"retries disabled", 0, "0", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			mockViaCEPResponse = `{"localidade": "São Paulo"}`
+			mockViaCEPThrottled.Store(100)
+			mockViaCEPRetryAfter = tc.retryAfter
+			httpRetryPolicy.MaxRetries = tc.maxRetries
+
+			rr := httptest.NewRecorder()
+			weatherHandler(rr, httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Fatalf("handler returned wrong status code: got %v want %v (body %q)", rr.Code, http.StatusServiceUnavailable, rr.Body.String())
+			}
+			if body := strings.TrimSpace(rr.Body.String()); body != errorViaCEPRateLimited {
+				t.Errorf("handler returned unexpected body: got %q want %q", body, errorViaCEPRateLimited)
+			}
+			if calls := mockViaCEPCalls.Load(); calls != tc.expectedCalls {
+				t.Errorf("got %d ViaCEP calls want %d", calls, tc.expectedCalls)
+			}
+			if calls := mockWeatherAPICalls.Load(); calls != 0 {
+				t.Errorf("expected WeatherAPI not to be called, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestViaCEPRateLimitedError_RetryDelay(t *testing.T) {
+	defer func(policy retryPolicy) { httpRetryPolicy = policy }(httpRetryPolicy)
+	httpRetryPolicy = retryPolicy{MaxRetries: 1, BaseDelay: 100 * time.Millisecond}
+
+	testCases := []struct {
+		name     string
+		err      viaCEPRateLimitedError
+		expected time.Duration
+		ok       bool
+	}{
+		{"retry-after", viaCEPRateLimitedError{retryAfter: 2 * time.Second, hasRetry: true}, 2 * time.Second, true},
+		{"backoff without retry-after", viaCEPRateLimitedError{}, 100 * time.Millisecond, true},
+		{"retry-after above the limit", viaCEPRateLimitedError{retryAfter: maxRetryBackoff + time.Second, hasRetry: true}, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := tc.err.retryDelay(context.Background(), 0)
+			if delay != tc.expected || ok != tc.ok {
+				t.Errorf("got (%v, %v) want (%v, %v)", delay, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	limited := viaCEPRateLimitedError{retryAfter: 2 * time.Second, hasRetry: true}
+	if _, ok := limited.retryDelay(ctx, 0); ok {
+		t.Error("expected a wait beyond the request deadline not to be retried")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	warmupCEPsEnv    = "WARMUP_CEPS"
+	warmupTimeoutEnv = "WARMUP_TIMEOUT"
+
+	defaultWarmupTimeout = 30 * time.Second
+)
+
+// warmupSummary resume o resultado do aquecimento do cache
+type warmupSummary struct {
+	warmed int
+	failed int
+}
+
+// parseWarmupCEPs lê a lista de CEPs separados por vírgula, ignorando entradas vazias e repetidas
+func parseWarmupCEPs(raw string) []string {
+	var ceps []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(raw, ",") {
+		cep := strings.TrimSpace(entry)
+		if cep == "" || seen[cep] {
+			continue
+		}
+		seen[cep] = true
+		ceps = append(ceps, cep)
+	}
+	return ceps
+}
+
+// warmupCEPCache resolve os CEPs antes de o servidor aceitar tráfego, populando o cache de
+// CEPs. Falhas são registradas e não interrompem o aquecimento; ao fim do prazo os CEPs
+// restantes são abandonados, para que a inicialização não fique presa a um ViaCEP lento.
+func warmupCEPCache(ctx context.Context, ceps []string, concurrency int, timeout time.Duration) warmupSummary {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	slog.InfoContext(ctx, "Cache warmup started", "ceps", len(ceps), "timeout", timeout)
+
+	var (
+		mu      sync.Mutex
+		summary warmupSummary
+		wg      sync.WaitGroup
+	)
+	jobs := make(chan string)
+	for range min(concurrency, len(ceps)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cep := range jobs {
+				ok := warmupCEP(ctx, cep)
+				mu.Lock()
+				if ok {
+					summary.warmed++
+				} else {
+					summary.failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i, cep := range ceps {
+		if ctx.Err() != nil {
+			// Prazo esgotado: os CEPs que não chegaram a ser consultados contam como falha
+			mu.Lock()
+			summary.failed += len(ceps) - i
+			mu.Unlock()
+			break
+		}
+		jobs <- cep
+	}
+	close(jobs)
+	wg.Wait()
+
+	slog.InfoContext(ctx, "Cache warmup completed", "warmed", summary.warmed, "failed", summary.failed, "duration", time.Since(start))
+	return summary
+}
+
+// warmupCEP resolve um único CEP, registrando o resultado
+func warmupCEP(ctx context.Context, cep string) bool {
+	if !isValidCEP(cep) {
+		slog.WarnContext(ctx, "Cache warmup skipped invalid CEP", "cep", cep)
+		return false
+	}
+
+	// Cada CEP tem seu próprio limite de tentativas, como no lote
+	location, _, err := lookupCEP(withAttemptBudget(ctx, maxFallbackAttempts), defaultClients, cep, true)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache warmup failed", "cep", cep, "error", err)
+		return false
+	}
+	slog.InfoContext(ctx, "Cache warmup resolved CEP", "cep", cep, "city", location.City)
+	return true
+}
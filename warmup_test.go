@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseWarmupCEPs(t *testing.T) {
+	ceps := parseWarmupCEPs(" 01001000, ,20040002,01001000,")
+	if expected := []string{"01001000", "20040002"}; !slices.Equal(ceps, expected) {
+		t.Errorf("got %v want %v", ceps, expected)
+	}
+	if ceps := parseWarmupCEPs(""); len(ceps) != 0 {
+		t.Errorf("expected no CEPs for an empty list, got %v", ceps)
+	}
+}
+
+func TestWarmupCEPCache(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	mockViaCEPByCEP = map[string]string{
+		"01001000": `{"localidade": "São Paulo", "uf": "SP"}`,
+		"20040002": `{"localidade": "Rio de Janeiro", "uf": "RJ"}`,
+		"99999999": `{"erro": true}`,
+	}
+
+	summary := warmupCEPCache(context.Background(), []string{"01001000", "20040002", "99999999", "123"}, 2, time.Second)
+
+	if summary.warmed != 2 || summary.failed != 2 {
+		t.Errorf("got %+v want 2 warmed and 2 failed", summary)
+	}
+	for cep, city := range map[string]string{"01001000": "São Paulo", "20040002": "Rio de Janeiro"} {
+		if cached, _, ok := cepCache.get(cep); !ok || cached.City != city {
+			t.Errorf("expected %s to be cached as %q, got %+v (ok=%t)", cep, city, cached, ok)
+		}
+	}
+	if _, _, ok := cepCache.get("99999999"); ok {
+		t.Error("expected failed lookups to stay out of the cache")
+	}
+
+	// Com o cache aquecido, a requisição não consulta o ViaCEP
+	callsBefore := mockViaCEPCalls.Load()
+	if _, hit, err := lookupCEP(context.Background(), defaultClients, "01001000", true); err != nil || !hit {
+		t.Errorf("expected a cache hit after warmup, got hit=%t err=%v", hit, err)
+	}
+	if calls := mockViaCEPCalls.Load(); calls != callsBefore {
+		t.Errorf("expected no ViaCEP call after warmup, got %d", calls-callsBefore)
+	}
+}
+
+func TestWarmupCEPCache_Timeout(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cepCacheTTL = time.Hour
+	mockViaCEPResponse = `{"localidade": "São Paulo"}`
+	mockViaCEPDelay = time.Second
+
+	start := time.Now()
+	summary := warmupCEPCache(context.Background(), []string{"01001000", "20040002", "30140071"}, 1, 50*time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected warmup to stop at the timeout, took %v", elapsed)
+	}
+	if summary.warmed != 0 || summary.failed != 3 {
+		t.Errorf("got %+v want 0 warmed and 3 failed", summary)
+	}
+}
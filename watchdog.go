@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	watchdogTimeoutEnv = "WATCHDOG_TIMEOUT"
+	watchdogExitEnv    = "WATCHDOG_EXIT"
+)
+
+// watchdog detecta quando o caminho de atendimento parece travado: se o contador de
+// heartbeat parar de avançar por mais que timeout, registra um erro crítico e,
+// opcionalmente, encerra o processo para que o orquestrador reinicie o container
+type watchdog struct {
+	heartbeat   atomic.Uint64
+	timeout     time.Duration
+	interval    time.Duration // Frequência de verificação do heartbeat
+	exitOnStall bool
+	exit        func(code int) // Injetável para testes (os.Exit em produção)
+}
+
+// newWatchdog cria um watchdog que verifica o heartbeat quatro vezes por período de timeout
+func newWatchdog(timeout time.Duration, exitOnStall bool) *watchdog {
+	return &watchdog{
+		timeout:     timeout,
+		interval:    timeout / 4,
+		exitOnStall: exitOnStall,
+		exit:        os.Exit,
+	}
+}
+
+// beat sinaliza que o caminho de atendimento continua respondendo
+func (wd *watchdog) beat() {
+	wd.heartbeat.Add(1)
+}
+
+// run verifica periodicamente o heartbeat até que ctx seja cancelado
+func (wd *watchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(wd.interval)
+	defer ticker.Stop()
+
+	last := wd.heartbeat.Load()
+	lastProgress := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if current := wd.heartbeat.Load(); current != last {
+				last = current
+				lastProgress = now
+				continue
+			}
+			if now.Sub(lastProgress) < wd.timeout {
+				continue
+			}
+
+			slog.Error("Watchdog heartbeat stalled, request-serving path appears wedged", "stalled_for", now.Sub(lastProgress).Round(time.Millisecond))
+			if wd.exitOnStall {
+				wd.exit(1)
+				return
+			}
+			lastProgress = now // Evita repetir o alerta a cada verificação
+		}
+	}
+}
+
+// runHeartbeat exercita periodicamente o caminho de atendimento e avança o heartbeat.
+// Se probe travar (ex: deadlock em um lock compartilhado), o heartbeat para de avançar.
+func runHeartbeat(ctx context.Context, wd *watchdog, probe func()) {
+	ticker := time.NewTicker(wd.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			probe()
+			wd.beat()
+		}
+	}
+}
+
+// probeServingPath adquire os locks compartilhados usados pelas requisições (cache e
+// rate limiter), travando caso algum deles esteja preso
+func probeServingPath() {
+	weatherCache.get("")
+	cepCache.get("")
+	if clientRateLimiter != nil {
+		clientRateLimiter.mu.Lock()
+		clientRateLimiter.mu.Unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdog_StalledHeartbeatTriggersExit(t *testing.T) {
+	wd := newWatchdog(40*time.Millisecond, true)
+	exited := make(chan int, 1)
+	wd.exit = func(code int) { exited <- code }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Simula um probe travado: o heartbeat nunca avança
+	var wedged sync.Mutex
+	wedged.Lock()
+	defer wedged.Unlock()
+	go runHeartbeat(ctx, wd, func() { wedged.Lock(); wedged.Unlock() })
+	go wd.run(ctx)
+
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog did not trigger for a stalled heartbeat")
+	}
+}
+
+func TestWatchdog_HealthyHeartbeatDoesNotExit(t *testing.T) {
+	wd := newWatchdog(40*time.Millisecond, true)
+	exited := make(chan int, 1)
+	wd.exit = func(code int) { exited <- code }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go runHeartbeat(ctx, wd, func() {})
+	go wd.run(ctx)
+
+	select {
+	case <-exited:
+		t.Fatal("watchdog triggered despite a healthy heartbeat")
+	case <-time.After(200 * time.Millisecond):
+	}
+}